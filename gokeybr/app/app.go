@@ -0,0 +1,56 @@
+package app
+
+import "time"
+
+// Run shows session on terminal, and feeds it with keys from terminal and Input
+func (a *App) Run() error {
+	defer a.scr.Fini()
+
+	a.queue = NewEventQueue()
+	if a.Input != nil {
+		if err := a.Input.Start(a.queue); err != nil {
+			return err
+		}
+		defer a.Input.Close()
+	}
+	if err := (terminalInput{a.scr, a.Clock}).Start(a.queue); err != nil {
+		return err
+	}
+	if !a.Zen || a.TimeLimit > 0 {
+		t := time.NewTicker(100 * time.Millisecond)
+		stopTicks := make(chan struct{})
+		defer func() {
+			t.Stop()
+			close(stopTicks)
+		}()
+		go func() {
+			for {
+				select {
+				case <-stopTicks:
+					return
+				case <-t.C:
+					a.queue.Tick()
+				}
+			}
+		}()
+	}
+
+	for {
+		a.render()
+		if a.Over() {
+			return nil
+		}
+		// process all keys that are waiting before rendering again,
+		// so slow rendering does not make queue grow
+		ev := a.queue.Next()
+		for i := 1; ev != nil; i++ {
+			if !a.HandleEvent(ev) {
+				return nil
+			}
+			if i == maxBatch { // rest waits in queue till next render
+				break
+			}
+			ev = a.queue.Pending()
+		}
+	}
+}
@@ -0,0 +1,683 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+func TestMain(m *testing.M) {
+	// do not touch real stats of user
+	home, err := ioutil.TempDir("", "gokeybr")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(seconds float64) {
+	c.now = c.now.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func newTestSession(text string) (*App, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return NewSession(text, clock), clock
+}
+
+func key(ch rune) tcell.Event {
+	return tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone)
+}
+
+var backspace = tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone)
+
+// typeText hits keys for each character of text, with gap seconds between them.
+// Returns result of last HandleEvent.
+func typeText(a *App, clock *fakeClock, text string, gap float64) bool {
+	cont := true
+	for _, ch := range text {
+		clock.advance(gap)
+		cont = a.HandleEvent(key(ch))
+	}
+	return cont
+}
+
+func TestTyping(t *testing.T) {
+	a, clock := newTestSession("abc d")
+	if !typeText(a, clock, "abc ", 0.5) {
+		t.Fatal("Session ended before text is typed")
+	}
+	if a.InputPosition != 4 {
+		t.Errorf("InputPosition = %d, want 4", a.InputPosition)
+	}
+	if typeText(a, clock, "d", 0.5) {
+		t.Error("Session should end when text is typed")
+	}
+	want := []float64{0, 0.5, 1, 1.5, 2}
+	for i, v := range want {
+		if a.Timeline[i] != v {
+			t.Errorf("Timeline = %v, want %v", a.Timeline, want)
+			break
+		}
+	}
+}
+
+func TestQueuedKeysKeepTheirTime(t *testing.T) {
+	a, clock := newTestSession("abc")
+	q := NewEventQueue()
+	for _, ch := range "abc" {
+		clock.advance(0.05)
+		q.pushLocal(&sourcedEvent{Event: key(ch), source: SourceLocal, captured: clock.Now()})
+	}
+	clock.advance(0.1) // keys wait in queue
+	for ev := q.Pending(); ev != nil; ev = q.Pending() {
+		a.HandleEvent(ev)
+	}
+	if a.Timeline[1] != 0.05 || a.Timeline[2] != 0.1 {
+		t.Errorf("Keys typed 50ms apart have timeline %v", a.Timeline)
+	}
+}
+
+func TestMustCorrect(t *testing.T) {
+	a, clock := newTestSession("abc")
+	typeText(a, clock, "ax", 0.1)
+	if a.InputPosition != 1 || string(a.ErrorInput) != "x" {
+		t.Fatalf("Got position %d and error input %q", a.InputPosition, string(a.ErrorInput))
+	}
+	typeText(a, clock, "b", 0.1) // right key does not help before correction
+	if a.InputPosition != 1 {
+		t.Errorf("Moved to %d without correcting error", a.InputPosition)
+	}
+	a.HandleEvent(backspace)
+	a.HandleEvent(backspace)
+	typeText(a, clock, "b", 0.1)
+	if a.InputPosition != 2 || a.Errors[1] != 2 {
+		t.Errorf("Got position %d and %d errors, want 2 and 2", a.InputPosition, a.Errors[1])
+	}
+}
+
+func TestErrorPolicies(t *testing.T) {
+	a, clock := newTestSession("abc")
+	a.Policy = StopOnError
+	typeText(a, clock, "axb", 0.1)
+	if a.InputPosition != 2 || len(a.ErrorInput) != 0 {
+		t.Errorf("stop: got position %d and error input %q", a.InputPosition, string(a.ErrorInput))
+	}
+
+	a, clock = newTestSession("abc")
+	a.Policy = FreeMode
+	typeText(a, clock, "axc", 0.1)
+	if a.InputPosition != 3 || !a.Mistyped[1] || a.Mistyped[2] {
+		t.Errorf("free: got position %d and mistyped %v", a.InputPosition, a.Mistyped)
+	}
+}
+
+func TestLifeReduction(t *testing.T) {
+	a, clock := newTestSession(strings.Repeat("a", 50))
+	a.MinSpeed = 60
+	typeText(a, clock, "aaa", 1) // 12 wpm
+	a.CheckWPM()
+	clock.advance(3)
+	a.CheckWPM()
+	if a.RemainingLife != InitialLife-3*time.Second {
+		t.Errorf("RemainingLife = %s, want %s", a.RemainingLife, InitialLife-3*time.Second)
+	}
+	clock.advance(8)
+	a.CheckWPM()
+	if !a.Over() {
+		t.Error("Session should be over when life ended")
+	}
+}
+
+func TestPause(t *testing.T) {
+	a, clock := newTestSession("abcd")
+	typeText(a, clock, "ab", 1)
+	a.HandleEvent(tcell.NewEventKey(PauseKey, 0, tcell.ModNone))
+	clock.advance(100)
+	typeText(a, clock, "x", 0) // ignored in pause
+	a.HandleEvent(&controlEvent{command: ControlResume})
+	typeText(a, clock, "c", 1)
+	if a.InputPosition != 3 || a.Timeline[2] != 2 {
+		t.Errorf("Got position %d and timeline %v", a.InputPosition, a.Timeline)
+	}
+	if len(a.Pauses) != 1 {
+		t.Errorf("Pauses = %v, want one pause", a.Pauses)
+	}
+	// second pause starts at its point of timeline, not counting first one
+	a.HandleEvent(tcell.NewEventKey(PauseKey, 0, tcell.ModNone))
+	clock.advance(50)
+	a.HandleEvent(tcell.NewEventKey(PauseKey, 0, tcell.ModNone))
+	want := []stats.PauseInterval{{Start: 1, End: 101}, {Start: 2, End: 52}}
+	if len(a.Pauses) != 2 || a.Pauses[0] != want[0] || a.Pauses[1] != want[1] {
+		t.Errorf("Pauses = %v, want %v", a.Pauses, want)
+	}
+	if s := a.Session(3); len(s.Pauses) != 1 || s.Pauses[0] != want[0] {
+		t.Errorf("Pauses of session = %v, want %v", s.Pauses, want[:1])
+	}
+}
+
+func TestTimedTest(t *testing.T) {
+	a, clock := newTestSession(strings.Repeat("ab ", 100))
+	a.TimeLimit = 10 * time.Second
+	typeText(a, clock, "ab ab", 1)
+	if a.Over() {
+		t.Fatal("Test is over too soon")
+	}
+	clock.advance(7)
+	if !a.Over() || !a.TimeUp {
+		t.Error("Test should be over after time limit")
+	}
+	if a.Duration() != 10 {
+		t.Errorf("Duration = %f, want 10", a.Duration())
+	}
+	if a.Test() != "time:10s" {
+		t.Errorf("Test = %q", a.Test())
+	}
+}
+
+func TestWordLimitAndSuddenDeath(t *testing.T) {
+	a, clock := newTestSession("one two three")
+	a.WordLimit = 2
+	if typeText(a, clock, "one two", 0.2) {
+		t.Error("Test should end after two words")
+	}
+
+	a, clock = newTestSession("one two three")
+	a.SuddenDeath = true
+	if typeText(a, clock, "onx", 0.2) || !a.Died {
+		t.Error("Sudden death test should end on first error")
+	}
+}
+
+func TestEndless(t *testing.T) {
+	a, clock := newTestSession("")
+	a.Source = TextSourceFunc(func() (string, error) {
+		return "aaaa", nil
+	})
+	var flushed []stats.Session
+	a.Flush = func(s stats.Session) {
+		flushed = append(flushed, s)
+	}
+	a.refill()
+	for i := 0; i <= StreamWindow; i++ {
+		clock.advance(0.1)
+		if !a.HandleEvent(key(a.Text[a.InputPosition])) {
+			t.Fatal("Endless session ended")
+		}
+	}
+	if len(flushed) != 1 || len(flushed[0].Text) != StreamWindow+1-streamKeep {
+		t.Fatalf("Expected one session of %d characters flushed", StreamWindow+1-streamKeep)
+	}
+	if !strings.HasPrefix(string(flushed[0].Text), "aaaa aaaa") {
+		t.Errorf("Chunks should be separated by space, got %q", string(flushed[0].Text[:10]))
+	}
+	if a.InputPosition != streamKeep {
+		t.Errorf("InputPosition = %d, want %d", a.InputPosition, streamKeep)
+	}
+	if s := a.Session(a.InputPosition); s.Timeline[0] <= 0 || s.Timeline[0] > 0.11 {
+		t.Errorf("Timeline of rest of session should start from flushed text, got %v", s.Timeline[:3])
+	}
+}
+
+func TestRender(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	scr := tcell.NewSimulationScreen("")
+	a, err := NewWithScreen("hello world", scr, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scr.Fini()
+	scr.SetSize(80, 24)
+	typeText(a, clock, "hello", 0.2)
+	a.render()
+	screen := screenText(scr)
+	for _, s := range []string{"Type this:", "hello", "world", "0.8 sec", "45.5%"} {
+		if !strings.Contains(screen, s) {
+			t.Errorf("Screen does not contain %q:\n%s", s, screen)
+		}
+	}
+}
+
+// screenText returns what is shown on simulation screen
+func screenText(scr tcell.SimulationScreen) string {
+	cells, w, _ := scr.GetContents()
+	screen := make([]rune, 0, len(cells))
+	for i, c := range cells {
+		if i > 0 && i%w == 0 {
+			screen = append(screen, '\n')
+		}
+		if len(c.Runes) > 0 {
+			screen = append(screen, c.Runes[0])
+		}
+	}
+	return string(screen)
+}
+
+func TestNewInput(t *testing.T) {
+	for _, kind := range InputKinds() {
+		in, err := NewInput(kind, DefaultURL, "", DefaultClockSubject)
+		if err != nil {
+			t.Errorf("%s: %s", kind, err)
+		}
+		if (in == nil) != (kind == InputLocal) {
+			t.Errorf("%s: got input %v", kind, in)
+		}
+	}
+	if _, err := NewInput("carrier-pigeon", DefaultURL, "", ""); err == nil {
+		t.Error("Expected error for unknown input")
+	}
+}
+
+func remoteKey(ch rune) tcell.Event {
+	return EventMsg{Key: tcell.KeyRune, Char: ch, Source: "alice"}.Event()
+}
+
+func TestInputPolicies(t *testing.T) {
+	a, _ := newTestSession("abcd")
+	if !a.HandleEvent(EventMsg{Key: tcell.KeyEscape}.Event()) {
+		t.Error("Remote Escape should not end session")
+	}
+	a.InputPolicy = RemoteOnly
+	a.HandleEvent(key('a'))
+	a.HandleEvent(remoteKey('a'))
+	if a.InputPosition != 1 || a.Typists[0] != "alice" {
+		t.Errorf("remote: got position %d, typists %v", a.InputPosition, a.Typists)
+	}
+	a.InputPolicy = LocalOnly
+	a.HandleEvent(remoteKey('b'))
+	a.HandleEvent(key('b'))
+	if a.InputPosition != 2 || a.Typists[1] != SourceLocal {
+		t.Errorf("local: got position %d, typists %v", a.InputPosition, a.Typists)
+	}
+	if by := a.TypedBy(); by["alice"] != 1 || by[SourceLocal] != 1 {
+		t.Errorf("TypedBy = %v", by)
+	}
+}
+
+func TestPairTyping(t *testing.T) {
+	a, _ := newTestSession("ab cd ef")
+	a.InputPolicy = PairTyping
+	for _, ev := range []tcell.Event{
+		key('a'), remoteKey('b'), key('b'), key(' '), // local types first word
+		key('c'), remoteKey('c'), remoteKey('d'), remoteKey(' '), // then remote
+		key('e'),
+	} {
+		a.HandleEvent(ev)
+	}
+	want := []string{"local", "local", "local", "alice", "alice", "alice", "local"}
+	for i, w := range want {
+		if a.Typists[i] != w {
+			t.Fatalf("Typists = %v, want %v", a.Typists[:len(want)], want)
+		}
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	q := NewEventQueue()
+	q.Tick()
+	q.Tick()
+	for i := 0; i < QueueSize+3; i++ {
+		q.Push(key('a'))
+	}
+	if q.Dropped() != 3 {
+		t.Errorf("Dropped = %d, want 3", q.Dropped())
+	}
+	for i := 0; i < QueueSize; i++ {
+		if _, ok := q.Next().(*tcell.EventKey); !ok {
+			t.Fatal("Keys should be processed before ticks")
+		}
+	}
+	if _, ok := q.Next().(tick); !ok {
+		t.Error("Expected tick after keys")
+	}
+	select {
+	case <-q.ticks:
+		t.Error("Ticks should be coalesced")
+	default:
+	}
+}
+
+type fakeInput struct {
+	status InputStatus
+}
+
+func (f fakeInput) Start(*EventQueue) error { return nil }
+func (f fakeInput) Close()                  {}
+func (f fakeInput) Status() InputStatus     { return f.status }
+
+func TestLinkStatus(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	scr := tcell.NewSimulationScreen("")
+	a, err := NewWithScreen("hello world", scr, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scr.Fini()
+	scr.SetSize(100, 24)
+	a.Input = fakeInput{InputStatus{
+		State:     LinkDisconnected,
+		LastEvent: clock.now.Add(-2 * time.Second),
+		Pending:   3,
+	}}
+	a.render()
+	want := "NATS disconnected, last key 2.0s ago, backlog 3"
+	if screen := screenText(scr); !strings.Contains(screen, want) {
+		t.Errorf("Screen does not contain %q:\n%s", want, screen)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	a, clock := newTestSession("abc")
+	ev := &sourcedEvent{
+		Event:     tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+		source:    "alice",
+		captured:  clock.now.Add(-30 * time.Millisecond),
+		published: clock.now.Add(-20 * time.Millisecond),
+		received:  clock.now,
+	}
+	a.HandleEvent(ev)
+	clock.advance(0.005)
+	a.rendered()
+	for name, want := range map[string]float64{
+		stats.LatencyCaptureReceive: 30,
+		stats.LatencyPublishReceive: 20,
+		stats.LatencyReceiveRender:  5,
+	} {
+		h := a.Latency[name]
+		if h == nil || h.Count != 1 || h.Max < want-0.01 || h.Max > want+0.01 {
+			t.Errorf("%s: got %+v, want %.0f ms", name, h, want)
+		}
+	}
+}
+
+func TestClockSync(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	// publisher clock is 100ms ahead, network takes 10ms each way, reply takes 1ms
+	r := ClockReply{
+		T0: t0,
+		T1: t0.Add(110 * time.Millisecond),
+		T2: t0.Add(111 * time.Millisecond),
+	}
+	offset, delay := clockSample(r, t0.Add(21*time.Millisecond))
+	if offset != 100*time.Millisecond || delay != 20*time.Millisecond {
+		t.Fatalf("Got offset %s and delay %s, want 100ms and 20ms", offset, delay)
+	}
+	var c clockSync
+	c.add("alice", 150*time.Millisecond, 80*time.Millisecond) // slow exchange, less precise
+	c.add("alice", offset, delay)
+	ev := EventMsg{Key: tcell.KeyRune, Char: 'a', Source: "alice", Time: t0}.Event()
+	if got := c.correct(ev).(*sourcedEvent).captured; !got.Equal(t0.Add(-offset)) {
+		t.Errorf("Corrected capture time is %s, want %s", got, t0.Add(-offset))
+	}
+	if est := c.estimates(); len(est) != 1 || est[0].Offset != 100 || est[0].Samples != 2 {
+		t.Errorf("estimates = %+v", est)
+	}
+}
+
+func TestRemoteKeysKeepTheirTime(t *testing.T) {
+	a, clock := newTestSession("abcd")
+	start := clock.now
+	var c clockSync
+	offset := 3 * time.Second // clock of publisher is ahead
+	c.add("alice", offset, time.Millisecond)
+	remote := func(ch rune, captured time.Time) {
+		a.HandleEvent(c.correct(EventMsg{
+			Key: tcell.KeyRune, Char: ch, Source: "alice", Time: captured.Add(offset),
+		}.Event()))
+	}
+	clock.advance(1) // keys were typed a second ago, and arrive together
+	remote('a', start)
+	remote('b', start.Add(250*time.Millisecond))
+	remote('c', start.Add(100*time.Millisecond)) // out of order, does not go back
+	remote('d', start.Add(-time.Hour))           // stamp is too old to be trusted
+	if !a.StartedAt.Equal(start) {
+		t.Errorf("Session started at %s, want %s", a.StartedAt, start)
+	}
+	want := []float64{0, 0.25, 0.25, 1}
+	for i, w := range want {
+		if math.Abs(a.Timeline[i]-w) > 1e-9 {
+			t.Errorf("Timeline = %v, want %v", a.Timeline, want)
+			break
+		}
+	}
+}
+
+func TestEmbeddedServer(t *testing.T) {
+	srv := StartTestServer(t)
+
+	in, err := NewInput(InputJetStreamPull, srv.ClientURL(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewEventQueue()
+	if err := in.Start(q); err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, _ := nc.JetStream()
+	data, _ := json.Marshal(EventMsg{Key: tcell.KeyRune, Char: 'a', Source: "alice"})
+	if _, err := js.Publish(DefaultSubject(InputJetStreamPull), data); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); q.Queued() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Key published to provisioned stream was not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ev, source := untag(q.Pending()); source != "alice" || ev.(*tcell.EventKey).Rune() != 'a' {
+		t.Errorf("Received %v from %q", ev, source)
+	}
+}
+
+func TestService(t *testing.T) {
+	srv := StartTestServer(t)
+
+	saved := make(chan string, 2)
+	s := &Service{
+		URL:            srv.ClientURL(),
+		Subject:        "keys.*",
+		ResultsSubject: "results",
+		NewSession: func(typist string) (*App, error) {
+			return NewSession("ab", SystemClock), nil
+		},
+		Save: func(typist string, a *App) error {
+			saved <- typist + ":" + string(a.Text[:a.InputPosition])
+			return nil
+		},
+		OnError: func(typist string, err error) { t.Error(typist, err) },
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	results, err := nc.SubscribeSync("results.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc.Flush()
+	send := func(typist string, ch rune) {
+		data, _ := json.Marshal(EventMsg{Key: tcell.KeyRune, Char: ch})
+		nc.Publish("keys."+typist, data)
+	}
+	send("alice", 'a') // starts session, and is typed
+	start, _ := json.Marshal(EventMsg{Control: ControlStart})
+	nc.Publish("keys.bob", start)
+	send("alice", 'b')
+	send("bob", 'a')
+
+	select {
+	case got := <-saved:
+		if got != "alice:ab" {
+			t.Errorf("Saved %q, want alice:ab", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Session of alice did not end")
+	}
+	s.Stop() // session of bob is not finished, it is saved on stop
+	if got := <-saved; got != "bob:a" {
+		t.Errorf("Saved %q, want bob:a", got)
+	}
+	var done []Progress
+	for {
+		msg, err := results.NextMsg(100 * time.Millisecond)
+		if err != nil {
+			break
+		}
+		var p Progress
+		json.Unmarshal(msg.Data, &p)
+		if p.Done {
+			done = append(done, p)
+		}
+	}
+	if len(done) != 2 || done[0].Typist != "alice" || done[0].Typed != 2 || done[0].Summary == "" {
+		t.Errorf("Results of sessions: %+v", done)
+	}
+}
+
+func TestServiceProfiles(t *testing.T) {
+	srv := StartTestServer(t)
+	saved := make(chan string, 2)
+	s := &Service{
+		URL:            srv.ClientURL(),
+		Subject:        "keys.*",
+		ResultsSubject: "results",
+		NewSession: func(typist string) (*App, error) {
+			a := NewSession("hello", SystemClock)
+			a.Stats = stats.ProfileStore(typist)
+			return a, nil
+		},
+		Save: func(typist string, a *App) error {
+			defer func() { saved <- typist }()
+			return a.Stats.SaveSession(a.Session(a.InputPosition))
+		},
+		OnError: func(typist string, err error) { t.Error(typist, err) },
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	for _, ch := range "hello" { // typists type at the same time
+		for _, typist := range []string{"carol", "dave"} {
+			data, _ := json.Marshal(EventMsg{Key: tcell.KeyRune, Char: ch})
+			nc.Publish("keys."+typist, data)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-saved:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Sessions did not end")
+		}
+	}
+	for _, profile := range []string{"carol", "dave"} {
+		iter, err := fs.ProfileFiles(profile).NewJSONLinesIterator(stats.LogStatsFile)
+		if err != nil {
+			t.Fatalf("Session of %s is not saved to own profile: %v", profile, err)
+		}
+		var entry struct{ Text string }
+		if ok, err := iter.UnmarshalNextLine(&entry); !ok || err != nil || entry.Text != "hello" {
+			t.Errorf("Log of %s starts with %+v, %v", profile, entry, err)
+		}
+		iter.Close()
+	}
+	if _, err := fs.NewJSONLinesIterator(stats.LogStatsFile); err == nil {
+		t.Error("Sessions of typists should not be saved to current profile")
+	}
+}
+
+func TestNATSTextSourceDrops(t *testing.T) {
+	srv := StartTestServer(t)
+
+	s, err := NewNATSTextSource(srv.ClientURL(), "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	for i := 0; i < textChunks+10; i++ {
+		nc.Publish("text", []byte("chunk"))
+	}
+	nc.Flush()
+	for deadline := time.Now().Add(5 * time.Second); s.Dropped() < 10; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Dropped %d chunks, want 10", s.Dropped())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < textChunks; i++ {
+		if chunk, _ := s.Next(); chunk != "chunk" {
+			t.Fatalf("Next() = %q", chunk)
+		}
+	}
+
+	// typist caught up, and sees where text was dropped
+	nc.Publish("text", []byte("after"))
+	nc.Flush()
+	a, _ := newTestSession("before")
+	a.Source = s
+	for deadline := time.Now().Add(5 * time.Second); len(a.Text) == len("before"); a.refill() {
+		if time.Now().After(deadline) {
+			t.Fatal("Chunk published after drop was not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := string(a.Text); got != "before ... after" {
+		t.Errorf("Text with dropped chunks is %q", got)
+	}
+}
+
+func TestServiceIdleTimeout(t *testing.T) {
+	s := &Service{IdleTimeout: time.Minute, done: make(chan struct{})}
+	a, clock := newTestSession("abc")
+	lastKey := clock.Now()
+	if !s.step(a, tcell.NewEventKey(PauseKey, 0, tcell.ModNone), &lastKey) {
+		t.Fatal("Pause ended session")
+	}
+	clock.advance(120)
+	if !s.step(a, tick{}, &lastKey) {
+		t.Error("Paused session should wait longer than idle timeout")
+	}
+	clock.advance(60 * pausedIdleFactor)
+	if s.step(a, tick{}, &lastKey) {
+		t.Error("Paused session should end when typist left")
+	}
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// InputPolicy decides whose keys are typed, when they come both from
+// terminal keyboard and from remote keystream
+type InputPolicy int
+
+const (
+	// Merged types keys from everyone
+	Merged InputPolicy = iota
+	// RemoteOnly types only remote keys, local keyboard is used only to quit and pause
+	RemoteOnly
+	// LocalOnly ignores remote keys, only remote control commands are accepted
+	LocalOnly
+	// PairTyping makes local and remote typists take turns, passing
+	// typing to each other after each word
+	PairTyping
+)
+
+var inputPolicyNames = []string{"merged", "remote", "local", "pair"}
+
+func (p InputPolicy) String() string {
+	return inputPolicyNames[p]
+}
+
+func InputPolicies() []string {
+	return inputPolicyNames
+}
+
+func ParseInputPolicy(name string) (InputPolicy, error) {
+	for i, n := range inputPolicyNames {
+		if n == name {
+			return InputPolicy(i), nil
+		}
+	}
+	return Merged, fmt.Errorf(
+		"unknown input policy %q, available: %s", name, strings.Join(inputPolicyNames, ", "),
+	)
+}
+
+// Names of event sources. Remote typist could also be named in EventMsg.Source.
+const (
+	SourceLocal  = "local"
+	SourceRemote = "remote"
+)
+
+// sourcedEvent is event tagged with name of its source. Remote events
+// also have time when they were captured, published and received.
+type sourcedEvent struct {
+	tcell.Event
+	source                        string
+	captured, published, received time.Time
+}
+
+// When returns time when event was captured (for remote events it is time of
+// publisher, corrected by offset of its clock when it is known), or time when
+// event was created, if it was not stamped.
+func (e *sourcedEvent) When() time.Time {
+	if !e.captured.IsZero() {
+		return e.captured
+	}
+	return e.Event.When()
+}
+
+func tagged(ev tcell.Event, source string) tcell.Event {
+	return &sourcedEvent{Event: ev, source: source}
+}
+
+// untag returns event without tag, and its source. Untagged events are local.
+func untag(ev tcell.Event) (tcell.Event, string) {
+	if s, ok := ev.(*sourcedEvent); ok {
+		return s.Event, s.source
+	}
+	return ev, SourceLocal
+}
+
+// side returns SourceLocal for local source, and SourceRemote for any remote one
+func side(source string) string {
+	if source == SourceLocal {
+		return SourceLocal
+	}
+	return SourceRemote
+}
+
+func isQuitKey(ev *tcell.EventKey) bool {
+	return ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC
+}
+
+// accepts returns true when key from source should be processed
+func (a *App) accepts(ev *tcell.EventKey, source string) bool {
+	local := side(source) == SourceLocal
+	if isQuitKey(ev) { // only the one at the terminal could quit
+		return local
+	}
+	if ev.Key() == PauseKey {
+		return true
+	}
+	switch a.InputPolicy {
+	case RemoteOnly:
+		return !local
+	case LocalOnly:
+		return local
+	case PairTyping:
+		return a.Turn == "" || a.Turn == side(source)
+	}
+	return true
+}
+
+// passTurn gives typing to other side in pair typing, when word was finished
+func (a *App) passTurn(source string, typedBefore int) {
+	if a.InputPolicy != PairTyping {
+		return
+	}
+	if a.Turn == "" {
+		a.Turn = side(source)
+	}
+	if a.InputPosition <= typedBefore || !unicode.IsSpace(a.Text[a.InputPosition-1]) {
+		return
+	}
+	if a.Turn == SourceLocal {
+		a.Turn = SourceRemote
+	} else {
+		a.Turn = SourceLocal
+	}
+}
+
+// TypedBy counts characters typed by each source
+func (a *App) TypedBy() map[string]int {
+	res := make(map[string]int)
+	for t, n := range a.flushedTypists {
+		res[t] += n
+	}
+	for _, t := range a.Typists[:a.InputPosition] {
+		res[t]++
+	}
+	return res
+}
+
+// typistsSummary tells how much each typist typed, when not everything
+// was typed on local keyboard
+func (a *App) typistsSummary() string {
+	by := a.TypedBy()
+	if len(by) == 1 && by[SourceLocal] > 0 {
+		return ""
+	}
+	names := make([]string, 0, len(by))
+	for t := range by {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, t := range names {
+		parts = append(parts, fmt.Sprintf("%s: %d", t, by[t]))
+	}
+	return fmt.Sprintf("Characters typed by %s\n", strings.Join(parts, ", "))
+}
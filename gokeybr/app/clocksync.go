@@ -0,0 +1,172 @@
+package app
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultClockSubject is a subject where publishers answer time requests
+const DefaultClockSubject = "gokeybr.clock"
+
+const (
+	// ClockSyncInterval is how often clocks of publishers are checked during session
+	ClockSyncInterval = 10 * time.Second
+	// how long to wait for replies of publishers
+	clockReplyWait = 500 * time.Millisecond
+	// number of last samples kept for each publisher, the one with lowest delay is used
+	clockSamples = 8
+)
+
+// ClockRequest is sent by trainer to publishers, T0 is trainer time of sending
+type ClockRequest struct {
+	T0 time.Time
+}
+
+// ClockReply is sent by publisher back: T1 is its time of receiving the
+// request, and T2 of sending reply
+type ClockReply struct {
+	T0, T1, T2 time.Time
+	Source     string
+}
+
+// clockSample computes offset of publisher clock from trainer one, and round
+// trip delay of network, from reply received at t3 (like NTP does)
+func clockSample(r ClockReply, t3 time.Time) (offset, delay time.Duration) {
+	offset = (r.T1.Sub(r.T0) + r.T2.Sub(t3)) / 2
+	delay = t3.Sub(r.T0) - r.T2.Sub(r.T1)
+	return
+}
+
+type clockMeasure struct {
+	offset, delay time.Duration
+}
+
+// clockSync estimates clock offsets of publishers, by sources
+type clockSync struct {
+	mu      sync.Mutex
+	samples map[string][]clockMeasure
+}
+
+func (c *clockSync) add(source string, offset, delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.samples == nil {
+		c.samples = make(map[string][]clockMeasure)
+	}
+	s := append(c.samples[source], clockMeasure{offset, delay})
+	if len(s) > clockSamples {
+		s = s[len(s)-clockSamples:]
+	}
+	c.samples[source] = s
+}
+
+// best returns sample with lowest delay, it is the least affected by network
+func best(samples []clockMeasure) clockMeasure {
+	b := samples[0]
+	for _, s := range samples[1:] {
+		if s.delay < b.delay {
+			b = s
+		}
+	}
+	return b
+}
+
+// offset returns estimated offset of source clock
+func (c *clockSync) offset(source string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.samples[source]
+	if len(s) == 0 {
+		return 0, false
+	}
+	return best(s).offset, true
+}
+
+// estimates returns current estimates of clock offsets, for session log
+func (c *clockSync) estimates() []stats.ClockOffset {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]stats.ClockOffset, 0, len(c.samples))
+	for source, s := range c.samples {
+		b := best(s)
+		res = append(res, stats.ClockOffset{
+			Source:  source,
+			Offset:  float64(b.offset) / float64(time.Millisecond),
+			Delay:   float64(b.delay) / float64(time.Millisecond),
+			Samples: len(s),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Source < res[j].Source })
+	return res
+}
+
+// correct converts publisher timestamps of event to trainer clock
+func (c *clockSync) correct(ev tcell.Event) tcell.Event {
+	s, ok := ev.(*sourcedEvent)
+	if !ok {
+		return ev
+	}
+	offset, ok := c.offset(s.source)
+	if !ok {
+		return ev
+	}
+	if !s.captured.IsZero() {
+		s.captured = s.captured.Add(-offset)
+	}
+	if !s.published.IsZero() {
+		s.published = s.published.Add(-offset)
+	}
+	return s
+}
+
+// sync asks publishers for their time, and adds sample for each reply
+func (c *clockSync) sync(nc *nats.Conn, subject string) error {
+	inbox := nats.NewInbox()
+	sub, err := nc.SubscribeSync(inbox)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	t0 := time.Now()
+	data, err := json.Marshal(ClockRequest{T0: t0})
+	if err != nil {
+		return err
+	}
+	if err := nc.PublishRequest(subject, inbox, data); err != nil {
+		return err
+	}
+	deadline := t0.Add(clockReplyWait)
+	for {
+		msg, err := sub.NextMsg(time.Until(deadline))
+		if err != nil { // timeout, all replies received
+			return nil
+		}
+		t3 := time.Now()
+		var r ClockReply
+		if err := json.Unmarshal(msg.Data, &r); err != nil || !r.T0.Equal(t0) {
+			continue
+		}
+		offset, delay := clockSample(r, t3)
+		c.add(remoteSource(r.Source), offset, delay)
+	}
+}
+
+// run syncs clocks at start and then periodically, until done is closed
+func (c *clockSync) run(nc *nats.Conn, subject string, done <-chan struct{}) {
+	t := time.NewTicker(ClockSyncInterval)
+	defer t.Stop()
+	for {
+		c.sync(nc, subject)
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+	}
+}
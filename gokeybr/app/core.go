@@ -0,0 +1,374 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/bunyk/gokeybr/view"
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/encoding"
+)
+
+// used for testing
+// j - type, k - untype
+const cheating = false
+
+const InitialLife = 10 * time.Second
+
+// Clock tells current time. App uses it instead of time.Now, so it could be tested.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is a real time clock
+var SystemClock Clock = systemClock{}
+
+// maxKeyAge is how long key could wait to be handled and keep its own time
+const maxKeyAge = 10 * time.Second
+
+// eventTime returns when key (or control command) happened. Keys are stamped
+// with time when they were captured, so keys waiting in queue keep their own
+// times, and events without stamp happen when handled. Time of events never
+// goes back, so keys of two typists captured out of order do not break timeline.
+// Stamps older than maxKeyAge are not trusted, as clock of publisher could be
+// far off when its offset is not known.
+func (a *App) eventTime(ev *sourcedEvent) time.Time {
+	now := a.Clock.Now()
+	if ev != nil && !ev.captured.IsZero() {
+		if when := ev.When(); when.Before(now) && now.Sub(when) < maxKeyAge {
+			now = when
+		}
+	}
+	if now.Before(a.lastEventAt) {
+		now = a.lastEventAt
+	}
+	a.lastEventAt = now
+	return now
+}
+
+// App holds whole app state
+type App struct {
+	Text          []rune
+	Timeline      []float64
+	InputPosition int
+	ErrorInput    []rune
+	StartedAt     time.Time
+	Offset        int
+	// Number of wrong keys hit before typing each character of Text
+	Errors []int
+	// Characters that were typed wrong, and not corrected (in FreeMode)
+	Mistyped []bool
+	// What happens when wrong key is hit
+	Policy ErrorPolicy
+
+	Zen  bool
+	Mute bool
+
+	// Minimal permitted speed
+	MinSpeed              int
+	LastLifeReductionTime time.Time
+	// For how long you could have your speed under speed limit and still continue typing
+	RemainingLife time.Duration
+
+	// Test modes: session ends when time limit is reached, given number
+	// of words is typed, or (in sudden death) on first error
+	TimeLimit   time.Duration
+	WordLimit   int
+	SuddenDeath bool
+	Died        bool
+	TimeUp      bool
+
+	// Session could be paused, time in pauses is not counted
+	Paused      bool
+	PausedAt    time.Time
+	PausedTotal time.Duration
+	Pauses      []stats.PauseInterval
+
+	// Source of keys besides terminal keyboard, nil for local session
+	Input InputSource
+	// Whose keys are typed, and whose turn is it in pair typing
+	InputPolicy InputPolicy
+	Turn        string
+	// Source that typed each character of Text
+	Typists []string
+	typist  string // source of key being processed
+
+	// Source of text for endless session, nil when text is fixed
+	Source    TextSource
+	SourceErr error
+	// Flush is called with old typed text of endless session, before it is
+	// removed from memory
+	Flush        func(stats.Session)
+	flushedChars int
+	flushedTime  float64
+	flushedIdle  float64
+	// characters typed by each source in flushed text
+	flushedTypists map[string]int
+	// Stats of typist, used to tell pauses from typing. Current profile by default.
+	Stats stats.Store
+
+	// Clock is a source of time, for tests it could be a fake
+	Clock Clock
+	// time of last key or control command
+	lastEventAt time.Time
+
+	queue *EventQueue
+
+	// Latencies of remote keys, and receive times of keys not rendered yet
+	Latency     map[string]*stats.Histogram
+	ShowLatency bool
+	unrendered  []time.Time
+
+	scr tcell.Screen
+}
+
+// NewSession creates state of typing session for text, without any screen.
+// It is changed by HandleEvent, and shown by ToDisplay.
+func NewSession(text string, clock Clock) *App {
+	a := &App{}
+	a.ErrorInput = make([]rune, 0, 20)
+	a.Text = []rune(text)
+	a.Timeline = make([]float64, len(a.Text))
+	a.Errors = make([]int, len(a.Text))
+	a.Mistyped = make([]bool, len(a.Text))
+	a.Typists = make([]string, len(a.Text))
+	a.RemainingLife = InitialLife
+	a.Clock = clock
+	a.Stats = stats.CurrentStore()
+	return a
+}
+
+// New creates typing session for text, shown on terminal
+func New(text string) (*App, error) {
+	encoding.Register()
+	scr, err := tcell.NewScreen()
+	if err != nil {
+		return NewSession(text, SystemClock), err
+	}
+	return NewWithScreen(text, scr, SystemClock)
+}
+
+// NewWithScreen creates typing session for text, shown on given screen
+func NewWithScreen(text string, scr tcell.Screen, clock Clock) (*App, error) {
+	a := NewSession(text, clock)
+	a.scr = scr
+	return a, scr.Init()
+}
+
+// tick will implement tcell.Event, and be used for updating timers on screen
+type tick struct {
+}
+
+func (t tick) When() time.Time {
+	return time.Time{} // no need to know real time yet
+}
+
+// HandleEvent updates state of session with event.
+// Returns false when session should end.
+func (a *App) HandleEvent(ev tcell.Event) bool {
+	stamps, _ := ev.(*sourcedEvent)
+	ev, source := untag(ev)
+	switch event := ev.(type) {
+	case *tcell.EventKey:
+		if !a.accepts(event, source) {
+			break
+		}
+		a.typist = source
+		a.measure(stamps)
+		typedBefore := a.InputPosition
+		if !a.processKey(event, a.eventTime(stamps)) {
+			if cheating {
+				a.InputPosition = 0
+			}
+			return false
+		}
+		a.passTurn(source, typedBefore)
+		a.flush()
+	case *controlEvent:
+		a.processControl(event, a.eventTime(stamps))
+	case *tcell.EventResize:
+		if a.scr != nil {
+			a.scr.Sync()
+		}
+	}
+	return !a.Over()
+}
+
+// Over returns true when session ended because of low speed, or test conditions
+func (a *App) Over() bool {
+	return a.RemainingLife <= 0 || a.testOver(a.Clock.Now())
+}
+
+// render draws session on screen
+func (a *App) render() {
+	a.refill()
+	view.Render(a.scr, a.ToDisplay())
+	a.rendered()
+}
+
+func log(v interface{}) {
+	fs.AppendJSONLine("debug.jsonl", v)
+}
+
+// wordsPerChar is used for computing WPM.
+// Word is considered to be in average 5 characters long.
+const wordsPerChar = 0.2
+const WPMWindow = 20
+
+func (a *App) CheckWPM() float64 {
+	wpm := 0.0
+	now := a.Clock.Now()
+	seconds := a.elapsed(now)
+	if a.InputPosition > 1 {
+		secondsPerWindow := seconds - a.Timeline[max(a.InputPosition-WPMWindow, 0)]
+		wpm = wordsPerChar * float64(min(WPMWindow, a.InputPosition)) / secondsPerWindow * 60.0
+
+		if a.MinSpeed > 0 && !a.Paused { // need to check speed limits
+			if wpm < float64(a.MinSpeed) { // speed below limit
+				if !a.LastLifeReductionTime.IsZero() { // speed was already below limit
+					diff := now.Sub(a.LastLifeReductionTime)
+					a.RemainingLife -= diff
+				}
+				a.LastLifeReductionTime = now
+			} else { // speed above limit, stop reductions
+				a.LastLifeReductionTime = time.Time{}
+			}
+		}
+	}
+	return wpm
+}
+
+func max(a, b int) int {
+	if a < b {
+		return b
+	}
+	return a
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (a *App) ToDisplay() view.DisplayableData {
+	wpm := a.CheckWPM()
+	now := a.Clock.Now()
+	life := 0.0
+	if a.MinSpeed > 0 {
+		life = float64(a.RemainingLife) / float64(InitialLife)
+	}
+	return view.DisplayableData{
+		DoneText:  a.Text[:a.InputPosition],
+		Mistyped:  a.Mistyped[:a.InputPosition],
+		WrongText: a.ErrorInput,
+		TODOText:  a.Text[a.InputPosition:],
+		StartedAt: a.StartedAt,
+		Elapsed:   a.elapsed(now),
+		Paused:    a.Paused,
+		TimeLeft:  a.timeLeft(now),
+		Timed:     a.TimeLimit > 0,
+		Words:     a.WordsTyped(),
+		WordLimit: a.WordLimit,
+		WPM:       wpm,
+		Life:      life,
+		Zen:       a.Zen,
+		Offset:    a.Offset,
+		Endless:   a.Endless(),
+		Turn:      a.Turn,
+		Dropped:   a.Dropped(),
+		Link:      a.linkStatus(now),
+	}
+}
+
+func (a App) Summary() string {
+	typed := a.flushedChars + a.InputPosition
+	if typed == 0 {
+		return "Typed nothing"
+	}
+	elapsed, idle := a.Stats.IdleTime(a.Timeline[:a.InputPosition])
+	// pauses in text flushed from memory were counted as typing
+	elapsed -= a.flushedIdle
+	idle += a.flushedIdle
+	if elapsed <= 0 {
+		return "Speed of light! (actually, probably some error with timer)"
+	}
+	pauses := ""
+	if idle > 0 {
+		pauses = fmt.Sprintf(" (and %4.1f seconds in pauses)", idle)
+	}
+	mistyped := 0
+	for _, m := range a.Mistyped[:a.InputPosition] {
+		if m {
+			mistyped++
+		}
+	}
+	uncorrected := ""
+	if mistyped > 0 {
+		uncorrected = fmt.Sprintf(", %d of them wrong", mistyped)
+	}
+	return fmt.Sprintf(
+		"Typed %d characters%s in %4.1f seconds%s. Speed: %4.1f wpm\n%s",
+		typed, uncorrected, elapsed, pauses, float64(typed)/elapsed*60.0/5.0, a.typistsSummary(),
+	) + a.droppedSummary()
+}
+
+// linkStatus returns status of remote input to show, or nil for local session
+func (a *App) linkStatus(now time.Time) *view.LinkStatus {
+	if a.Input == nil {
+		return nil
+	}
+	st := a.Input.Status()
+	ls := &view.LinkStatus{
+		State:      st.State,
+		LastEvent:  -1,
+		Backlog:    st.Pending,
+		AckPending: st.AckPending,
+	}
+	if !st.LastEvent.IsZero() {
+		ls.LastEvent = now.Sub(st.LastEvent).Seconds()
+	}
+	if a.queue != nil {
+		ls.Backlog += a.queue.Queued()
+	}
+	ls.Latency = a.latencyStatus()
+	return ls
+}
+
+// Dropped returns number of remote events dropped because trainer was too slow
+func (a *App) Dropped() int {
+	if a.queue == nil {
+		return 0
+	}
+	return a.queue.Dropped()
+}
+
+func (a *App) droppedSummary() string {
+	if a.Dropped() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d remote keys were dropped, because they came faster than trainer could process\n", a.Dropped())
+}
+
+// Compute number of typed lines
+func (a App) LinesTyped() int {
+	lt := 0
+	for _, c := range a.Text[:a.InputPosition] {
+		if c == '\n' {
+			lt++
+		}
+	}
+	if a.InputPosition == len(a.Text) {
+		lt++
+	}
+	return lt
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Commands that could be sent in EventMsg.Control to control session remotely
+const (
+	ControlPause  = "pause"
+	ControlResume = "resume"
+	// ControlStart starts session of service without typing anything
+	ControlStart = "start"
+)
+
+// EventMsg is a key event (or control command) received from NATS
+type EventMsg struct {
+	Time    time.Time // when key was captured by publisher
+	ModMask tcell.ModMask
+	Key     tcell.Key
+	Char    rune
+	Control string `json:",omitempty"`
+	// Name of remote typist, to tell who typed what
+	Source string `json:",omitempty"`
+	// When message was published, to measure latency
+	Published time.Time `json:",omitempty"`
+}
+
+// Event converts message to event for the event loop, tagged with its source
+func (m EventMsg) Event() tcell.Event {
+	source := remoteSource(m.Source)
+	var ev tcell.Event
+	if m.Control != "" {
+		ev = &controlEvent{when: time.Now(), command: m.Control}
+	} else {
+		ev = tcell.NewEventKey(m.Key, m.Char, m.ModMask)
+	}
+	return &sourcedEvent{
+		Event:     ev,
+		source:    source,
+		captured:  m.Time,
+		published: m.Published,
+		received:  time.Now(),
+	}
+}
+
+// remoteSource returns name of remote source, named by publisher
+func remoteSource(name string) string {
+	if name == "" || name == SourceLocal {
+		return SourceRemote
+	}
+	return name
+}
+
+// controlEvent implements tcell.Event, and is used for remote control of session
+type controlEvent struct {
+	when    time.Time
+	command string
+}
+
+func (e *controlEvent) When() time.Time {
+	return e.when
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ErrorPolicy defines what happens when wrong key is hit
+type ErrorPolicy int
+
+const (
+	// MustCorrect requires to backspace every wrong character before continuing
+	MustCorrect ErrorPolicy = iota
+	// StopOnError rejects wrong keys, cursor stays until right key is hit
+	StopOnError
+	// FreeMode accepts wrong characters, they advance cursor and are marked red.
+	// Backspace and Ctrl+Backspace could un-type them.
+	FreeMode
+	// WordCorrection is like MustCorrect, but Backspace also un-types correct
+	// text, and Ctrl+Backspace un-types whole word
+	WordCorrection
+)
+
+var policyNames = []string{"correct", "stop", "free", "word"}
+
+func (p ErrorPolicy) String() string {
+	return policyNames[p]
+}
+
+func ErrorPolicies() []string {
+	return policyNames
+}
+
+func ParseErrorPolicy(name string) (ErrorPolicy, error) {
+	for i, n := range policyNames {
+		if n == name {
+			return ErrorPolicy(i), nil
+		}
+	}
+	return MustCorrect, fmt.Errorf(
+		"unknown error policy %q, available: %s", name, strings.Join(policyNames, ", "),
+	)
+}
+
+// Return true when should continue loop. now is time when key was hit.
+func (a *App) processKey(ev *tcell.EventKey, now time.Time) bool {
+	if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
+		return false
+	}
+	if a.testOver(now) { // key was hit after end of test
+		return false
+	}
+	if ev.Key() == PauseKey {
+		a.togglePause(now)
+		return true
+	}
+	if a.Paused { // ignore typing in pause
+		return true
+	}
+
+	switch {
+	case isWordBackspace(ev):
+		a.processWordBackspace()
+	case ev.Key() == tcell.KeyBackspace, ev.Key() == tcell.KeyBackspace2:
+		a.processBackspace()
+	default:
+		return a.processCharInput(ev, now)
+	}
+	return true
+}
+
+// isWordBackspace returns true for Ctrl+Backspace, Alt+Backspace and Ctrl+W
+func isWordBackspace(ev *tcell.EventKey) bool {
+	if ev.Key() == tcell.KeyCtrlW {
+		return true
+	}
+	isBackspace := ev.Key() == tcell.KeyBackspace || ev.Key() == tcell.KeyBackspace2
+	return isBackspace && ev.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0
+}
+
+// canUntype is true for policies that allow to remove correctly typed text
+func (a *App) canUntype() bool {
+	return a.Policy == FreeMode || a.Policy == WordCorrection
+}
+
+func (a *App) processBackspace() {
+	if len(a.ErrorInput) > 0 {
+		a.ErrorInput = a.ErrorInput[:len(a.ErrorInput)-1]
+		return
+	}
+	if a.canUntype() {
+		a.untype()
+	}
+}
+
+func (a *App) processWordBackspace() {
+	if !a.canUntype() {
+		a.processBackspace()
+		return
+	}
+	a.ErrorInput = a.ErrorInput[:0]
+	for a.InputPosition > 0 && unicode.IsSpace(a.Text[a.InputPosition-1]) {
+		a.untype()
+	}
+	for a.InputPosition > 0 && !unicode.IsSpace(a.Text[a.InputPosition-1]) {
+		a.untype()
+	}
+}
+
+// untype moves cursor one character back. Timeline of that character
+// will be overwritten when it is typed again.
+func (a *App) untype() {
+	if a.InputPosition == 0 {
+		return
+	}
+	a.InputPosition--
+	a.Mistyped[a.InputPosition] = false
+}
+
+func (a *App) wrongKey() {
+	a.Errors[a.InputPosition]++
+	if a.SuddenDeath {
+		a.Died = true
+	}
+	if !a.Mute && a.scr != nil {
+		a.scr.Beep()
+	}
+}
+
+// Return true when should continue loop
+func (a *App) processCharInput(ev *tcell.EventKey, now time.Time) bool {
+	var ch rune
+	if ev.Key() == tcell.KeyRune {
+		ch = ev.Rune()
+	} else if ev.Key() == tcell.KeyEnter || ev.Key() == tcell.KeyCtrlJ {
+		ch = '\n'
+	}
+	if ch == 0 {
+		return true
+	}
+	if a.StartedAt.IsZero() {
+		a.StartedAt = now
+	}
+
+	if cheating { // always type correct :)
+		if ch == 'j' {
+			a.InputPosition += 3
+		}
+		if ch == 'k' {
+			a.InputPosition -= 3
+		}
+		if a.InputPosition < 0 {
+			a.InputPosition = 0
+		}
+		return a.InputPosition < len(a.Text)
+	}
+	if a.InputPosition >= len(a.Text) { // endless session waits for more text
+		return true
+	}
+	correct := ch == a.Text[a.InputPosition]
+	switch {
+	case a.Policy == FreeMode: // any character advances cursor
+		if !correct {
+			a.wrongKey()
+		}
+		a.Mistyped[a.InputPosition] = !correct
+		a.Timeline[a.InputPosition] = a.elapsed(now)
+		a.Typists[a.InputPosition] = a.typist
+		a.InputPosition++
+	case correct && len(a.ErrorInput) == 0:
+		a.Timeline[a.InputPosition] = a.elapsed(now)
+		a.Typists[a.InputPosition] = a.typist
+		a.InputPosition++
+	default: // wrong
+		a.wrongKey()
+		if a.Policy != StopOnError {
+			a.ErrorInput = append(a.ErrorInput, ch)
+		}
+	}
+	if a.Died {
+		return false
+	}
+	a.refill()
+	return a.InputPosition < len(a.Text) || a.Endless()
+}
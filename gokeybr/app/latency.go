@@ -0,0 +1,65 @@
+package app
+
+import (
+	"time"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/bunyk/gokeybr/view"
+)
+
+func (a *App) addLatency(name string, d time.Duration) {
+	if a.Latency == nil {
+		a.Latency = make(map[string]*stats.Histogram)
+	}
+	h := a.Latency[name]
+	if h == nil {
+		h = stats.NewHistogram()
+		a.Latency[name] = h
+	}
+	h.Add(d)
+}
+
+// measure records latencies of remote key, that was processed
+func (a *App) measure(ev *sourcedEvent) {
+	if ev == nil || ev.received.IsZero() {
+		return
+	}
+	if !ev.captured.IsZero() {
+		a.addLatency(stats.LatencyCaptureReceive, ev.received.Sub(ev.captured))
+	}
+	if !ev.published.IsZero() {
+		a.addLatency(stats.LatencyPublishReceive, ev.received.Sub(ev.published))
+	}
+	a.unrendered = append(a.unrendered, ev.received)
+}
+
+// rendered records latency from receiving keys till they are shown on screen
+func (a *App) rendered() {
+	if len(a.unrendered) == 0 {
+		return
+	}
+	now := a.Clock.Now()
+	for _, r := range a.unrendered {
+		a.addLatency(stats.LatencyReceiveRender, now.Sub(r))
+	}
+	a.unrendered = a.unrendered[:0]
+}
+
+// latencyStatus returns p50/p95/p99 of latencies measured, for status line
+func (a *App) latencyStatus() []view.Latency {
+	if !a.ShowLatency {
+		return nil
+	}
+	res := make([]view.Latency, 0, len(a.Latency))
+	for _, name := range []string{stats.LatencyCaptureReceive, stats.LatencyReceiveRender} {
+		if h := a.Latency[name]; h != nil {
+			res = append(res, view.Latency{
+				Name: name,
+				P50:  h.Percentile(50),
+				P95:  h.Percentile(95),
+				P99:  h.Percentile(99),
+			})
+		}
+	}
+	return res
+}
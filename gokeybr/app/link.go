@@ -0,0 +1,124 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/nats-io/nats.go"
+)
+
+// States of connection to NATS server
+const (
+	LinkConnecting   = "connecting"
+	LinkConnected    = "connected"
+	LinkDisconnected = "disconnected"
+	LinkReconnected  = "reconnected"
+	LinkClosed       = "closed"
+	// LinkRetrying is state of input that failed to receive, and tries again
+	LinkRetrying = "retrying"
+)
+
+// consumerPollInterval is how often JetStream consumer is asked for number of pending messages
+const consumerPollInterval = time.Second
+
+// InputStatus tells whether remote input works, and how much it lags
+type InputStatus struct {
+	State     string
+	LastEvent time.Time // zero when nothing was received yet
+	// Messages not yet delivered: for JetStream - pending in consumer,
+	// for core NATS - waiting in client buffer
+	Pending    int
+	AckPending int // JetStream messages delivered but not acknowledged yet
+	// Estimated offsets of publisher clocks
+	Clocks []stats.ClockOffset
+}
+
+// link keeps status of NATS input, updated by NATS callbacks
+type link struct {
+	mu     sync.Mutex
+	status InputStatus
+	done   chan struct{}
+	// clocks of publishers are synced with requests to clockSubject, if it is set
+	clockSubject string
+	clocks       clockSync
+}
+
+func (l *link) setState(state string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status.State = state
+}
+
+// received is called for every message received
+func (l *link) received() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status.LastEvent = time.Now()
+}
+
+func (l *link) setPending(pending, ackPending int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status.Pending = pending
+	l.status.AckPending = ackPending
+}
+
+func (l *link) Status() InputStatus {
+	l.mu.Lock()
+	st := l.status
+	l.mu.Unlock()
+	st.Clocks = l.clocks.estimates()
+	return st
+}
+
+// connect connects to NATS server, and tracks state of connection
+func (l *link) connect(url string) (*nats.Conn, error) {
+	l.done = make(chan struct{})
+	l.setState(LinkConnecting)
+	nc, err := nats.Connect(url,
+		nats.DisconnectErrHandler(func(*nats.Conn, error) {
+			l.setState(LinkDisconnected)
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			l.setState(LinkReconnected)
+		}),
+		nats.ClosedHandler(func(*nats.Conn) {
+			l.setState(LinkClosed)
+		}),
+	)
+	if err != nil {
+		l.setState(LinkClosed)
+		return nil, err
+	}
+	l.setState(LinkConnected)
+	if l.clockSubject != "" {
+		go l.clocks.run(nc, l.clockSubject, l.done)
+	}
+	return nc, nil
+}
+
+// watchConsumer polls JetStream consumer of subscription for number of
+// pending messages, till link is stopped
+func (l *link) watchConsumer(sub *nats.Subscription) {
+	go func() {
+		t := time.NewTicker(consumerPollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-t.C:
+			}
+			if info, err := sub.ConsumerInfo(); err == nil {
+				l.setPending(int(info.NumPending), info.NumAckPending)
+			}
+		}
+	}()
+}
+
+func (l *link) stop() {
+	if l.done != nil {
+		close(l.done)
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"time"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/gdamore/tcell/v2"
+)
+
+// PauseKey pauses and resumes session
+const PauseKey = tcell.KeyCtrlP
+
+func (a *App) pause(at time.Time) {
+	if a.Paused {
+		return
+	}
+	a.Paused = true
+	a.PausedAt = at
+}
+
+func (a *App) resume(at time.Time) {
+	if !a.Paused {
+		return
+	}
+	a.Paused = false
+	if !a.StartedAt.IsZero() {
+		// pause starts at its point of timeline, which does not include earlier pauses
+		start := a.elapsed(a.PausedAt)
+		a.Pauses = append(a.Pauses, stats.PauseInterval{
+			Start: start,
+			End:   start + at.Sub(a.PausedAt).Seconds(),
+		})
+		a.PausedTotal += at.Sub(a.PausedAt)
+	}
+	// do not count time in pause as time with speed below limit
+	a.LastLifeReductionTime = time.Time{}
+}
+
+func (a *App) togglePause(at time.Time) {
+	if a.Paused {
+		a.resume(at)
+	} else {
+		a.pause(at)
+	}
+}
+
+func (a *App) processControl(ev *controlEvent, now time.Time) {
+	switch ev.command {
+	case ControlPause:
+		a.pause(now)
+	case ControlResume:
+		a.resume(now)
+	}
+}
+
+// elapsed returns seconds since start of session till given time, not counting pauses
+func (a *App) elapsed(now time.Time) float64 {
+	if a.StartedAt.IsZero() {
+		return 0
+	}
+	if a.Paused {
+		now = a.PausedAt
+	}
+	return (now.Sub(a.StartedAt) - a.PausedTotal).Seconds()
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// QueueSize is a number of events from each source waiting to be processed.
+// When remote events come faster, they are dropped.
+const QueueSize = 256
+
+// maxBatch is a number of waiting events processed before screen is rendered again
+const maxBatch = 64
+
+// EventQueue collects events for the event loop. Keys are processed before
+// ticks, ticks are coalesced, and remote events never block their sender.
+type EventQueue struct {
+	local   chan tcell.Event
+	remote  chan tcell.Event
+	ticks   chan struct{}
+	dropped uint64
+}
+
+func NewEventQueue() *EventQueue {
+	return &EventQueue{
+		local:  make(chan tcell.Event, QueueSize),
+		remote: make(chan tcell.Event, QueueSize),
+		ticks:  make(chan struct{}, 1),
+	}
+}
+
+// Push adds remote event to queue without waiting. When queue is full, event
+// is dropped and false is returned.
+func (q *EventQueue) Push(ev tcell.Event) bool {
+	select {
+	case q.remote <- ev:
+		return true
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+}
+
+// pushLocal adds event from terminal, waiting when queue is full
+// (terminal keeps its own buffer, so nothing is lost)
+func (q *EventQueue) pushLocal(ev tcell.Event) {
+	q.local <- ev
+}
+
+// Tick asks for screen update. When there is update waiting already, nothing is added.
+func (q *EventQueue) Tick() {
+	select {
+	case q.ticks <- struct{}{}:
+	default:
+	}
+}
+
+// Dropped returns number of remote events that were dropped because queue was full
+func (q *EventQueue) Dropped() int {
+	return int(atomic.LoadUint64(&q.dropped))
+}
+
+// Queued returns number of remote events waiting to be processed
+func (q *EventQueue) Queued() int {
+	return len(q.remote)
+}
+
+// Next waits for next event, keys first
+func (q *EventQueue) Next() tcell.Event {
+	if ev := q.Pending(); ev != nil {
+		return ev
+	}
+	select {
+	case ev := <-q.local:
+		return ev
+	case ev := <-q.remote:
+		return ev
+	case <-q.ticks:
+		return tick{}
+	}
+}
+
+// Pending returns key (or other non-tick) event that is waiting, or nil
+func (q *EventQueue) Pending() tcell.Event {
+	select {
+	case ev := <-q.local:
+		return ev
+	default:
+	}
+	select {
+	case ev := <-q.remote:
+		return ev
+	default:
+		return nil
+	}
+}
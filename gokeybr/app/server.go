@@ -0,0 +1,125 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// StreamSubjects are subjects stored in stream provisioned by embedded server
+const StreamSubjects = "events.>"
+
+// streamMaxAge is how long keys are kept in provisioned stream
+const streamMaxAge = 24 * time.Hour
+
+// serverReadyWait is how long to wait for embedded server to accept connections
+const serverReadyWait = 5 * time.Second
+
+// ServerOptions configure NATS server embedded into gokeybr
+type ServerOptions struct {
+	Host          string
+	Port          int
+	WebsocketPort int    // 0 - no websocket listener
+	StoreDir      string // where JetStream keeps streams
+	Log           bool   // print log of server to stderr
+}
+
+// DefaultServerOptions listen where publisher and trainer connect by default,
+// and keep JetStream files in ~/.gokeybr/js
+func DefaultServerOptions() ServerOptions {
+	return ServerOptions{
+		Host:     "127.0.0.1",
+		Port:     server.DEFAULT_PORT,
+		StoreDir: fs.RootPath("js"),
+	}
+}
+
+// Server is NATS server with JetStream, running in the same process
+type Server struct {
+	ns *server.Server
+}
+
+// StartServer starts embedded NATS server, waits till it accepts connections,
+// and provisions stream JetStream inputs receive keys from
+func StartServer(opts ServerOptions) (*Server, error) {
+	sopts := &server.Options{
+		Host:      opts.Host,
+		Port:      opts.Port,
+		JetStream: true,
+		StoreDir:  opts.StoreDir,
+		NoSigs:    true,
+		NoLog:     !opts.Log,
+	}
+	if opts.WebsocketPort != 0 {
+		sopts.Websocket = server.WebsocketOpts{
+			Host:  opts.Host,
+			Port:  opts.WebsocketPort,
+			NoTLS: true, // local use only
+		}
+	}
+	ns, err := server.NewServer(sopts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Log {
+		ns.ConfigureLogger()
+	}
+	ns.Start()
+	s := &Server{ns: ns}
+	if !ns.ReadyForConnections(serverReadyWait) {
+		ns.Shutdown()
+		return nil, fmt.Errorf("NATS server did not start listening on %s:%d", opts.Host, opts.Port)
+	}
+	if err := s.provision(); err != nil {
+		ns.Shutdown()
+		return nil, err
+	}
+	return s, nil
+}
+
+// ClientURL returns URL publishers and trainer could connect to
+func (s *Server) ClientURL() string {
+	return s.ns.ClientURL()
+}
+
+// Shutdown stops server, closing connections of clients
+func (s *Server) Shutdown() {
+	s.ns.Shutdown()
+	s.ns.WaitForShutdown()
+}
+
+// Wait blocks until server is shut down
+func (s *Server) Wait() {
+	s.ns.WaitForShutdown()
+}
+
+// provision creates stream for JetStream inputs, when there is none yet
+func (s *Server) provision() error {
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		return err
+	}
+	_, err = js.StreamInfo(StreamName)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return err
+	}
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     StreamName,
+		Subjects: []string{StreamSubjects},
+		Storage:  nats.FileStorage,
+		MaxAge:   streamMaxAge,
+	})
+	return err
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// ServiceTickInterval is how often service checks sessions and publishes their progress
+	ServiceTickInterval = time.Second
+	// DefaultIdleTimeout is how long service waits for keys of typist before ending session
+	DefaultIdleTimeout = 5 * time.Minute
+	// pausedIdleFactor is how many times longer paused session waits for keys
+	pausedIdleFactor = 6
+)
+
+// Progress of session, published by service to results subject of typist
+type Progress struct {
+	Typist  string
+	Text    string `json:",omitempty"` // text to type, published when session starts
+	Typed   int
+	Length  int
+	Errors  int // wrong keys hit
+	WPM     float64
+	Elapsed float64
+	Paused  bool   `json:",omitempty"`
+	Done    bool   `json:",omitempty"`
+	Summary string `json:",omitempty"` // when session is done
+}
+
+// Service trains many typists at once, without terminal. Each typist publishes
+// keys to own subject matching wildcard Subject, last token of subject names
+// typist. First key of typist (or ControlStart command, when typist does not
+// know text yet) starts session, text of session and then its progress are
+// published to ResultsSubject.<typist>.
+type Service struct {
+	URL            string
+	Subject        string // like keys.*
+	ResultsSubject string
+	// Session ends when typist sends no keys for that long (paused session
+	// waits pausedIdleFactor times longer)
+	IdleTimeout time.Duration
+	// NewSession creates session for typist, and Save is called with it when
+	// it ends. For the same typist they are never called concurrently, and next
+	// session is created after previous one is saved. Sessions of different
+	// typists are created and saved concurrently.
+	NewSession func(typist string) (*App, error)
+	Save       func(typist string, a *App) error
+	// OnError is called when session could not be created or saved
+	OnError func(typist string, err error)
+
+	nc      *nats.Conn
+	mu      sync.Mutex
+	tenants map[string]*EventQueue
+	hooks   map[string]*sync.Mutex // serialize NewSession and Save of each typist
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Start subscribes to keys of typists, and returns without waiting
+func (s *Service) Start() error {
+	if s.IdleTimeout == 0 {
+		s.IdleTimeout = DefaultIdleTimeout
+	}
+	s.tenants = make(map[string]*EventQueue)
+	s.hooks = make(map[string]*sync.Mutex)
+	s.done = make(chan struct{})
+	var err error
+	if s.nc, err = nats.Connect(s.URL); err != nil {
+		return err
+	}
+	if _, err = s.nc.Subscribe(s.Subject, func(msg *nats.Msg) {
+		tokens := strings.Split(msg.Subject, ".")
+		if ev := decodeEvent(msg.Data); ev != nil {
+			s.dispatch(tokens[len(tokens)-1], ev)
+		}
+	}); err != nil {
+		s.nc.Close()
+		return err
+	}
+	return nil
+}
+
+// Stop ends sessions of all typists, saving them, and disconnects
+func (s *Service) Stop() {
+	close(s.done)
+	s.mu.Lock()
+	for _, q := range s.tenants {
+		q.Tick()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.nc.Flush() // send results of ended sessions
+	s.nc.Close()
+}
+
+// dispatch passes event to session of typist, or starts new session
+func (s *Service) dispatch(typist string, ev tcell.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q := s.tenants[typist]; q != nil {
+		q.Push(ev)
+		return
+	}
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	q := NewEventQueue()
+	q.Push(ev) // key that starts session is typed too
+	s.tenants[typist] = q
+	s.wg.Add(1)
+	go s.train(typist, q)
+}
+
+// forget removes queue of typist, so next key of typist starts new session
+func (s *Service) forget(typist string, q *EventQueue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tenants[typist] == q {
+		delete(s.tenants, typist)
+	}
+}
+
+// hooksLock returns mutex that serializes NewSession and Save of typist
+func (s *Service) hooksLock(typist string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.hooks[typist]
+	if l == nil {
+		l = &sync.Mutex{}
+		s.hooks[typist] = l
+	}
+	return l
+}
+
+// train runs session of typist with events from queue, till it ends
+func (s *Service) train(typist string, q *EventQueue) {
+	defer s.wg.Done()
+
+	hooks := s.hooksLock(typist)
+	hooks.Lock()
+	a, err := s.NewSession(typist)
+	hooks.Unlock()
+	if err != nil {
+		s.forget(typist, q)
+		s.OnError(typist, err)
+		return
+	}
+	a.queue = q
+	p := a.Progress(typist)
+	p.Text = string(a.Text)
+	s.publish(p)
+
+	t := time.NewTicker(ServiceTickInterval)
+	stopTicks := make(chan struct{})
+	defer func() {
+		t.Stop()
+		close(stopTicks)
+	}()
+	go func() {
+		for {
+			select {
+			case <-stopTicks:
+				return
+			case <-t.C:
+				q.Tick()
+			}
+		}
+	}()
+
+	lastKey := a.Clock.Now()
+	for s.step(a, q.Next(), &lastKey) {
+		// publish only when something was typed, or session paused
+		last := p
+		if p = a.Progress(typist); p.Typed != last.Typed || p.Errors != last.Errors || p.Paused != last.Paused {
+			s.publish(p)
+			a.rendered()
+		}
+	}
+
+	// keys sent from now on start next session, it is created after this one
+	// is saved and its results are published
+	hooks.Lock()
+	defer hooks.Unlock()
+	s.forget(typist, q)
+	if err := s.Save(typist, a); err != nil {
+		s.OnError(typist, err)
+	}
+	p = a.Progress(typist)
+	p.Done = true
+	p.Summary = a.Summary()
+	s.publish(p)
+}
+
+// step handles event in session, and returns false when session should end
+func (s *Service) step(a *App, ev tcell.Event, lastKey *time.Time) bool {
+	now := a.Clock.Now()
+	if _, ok := ev.(tick); !ok {
+		*lastKey = now
+		return a.HandleEvent(ev)
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	timeout := s.IdleTimeout
+	if a.Paused {
+		timeout *= pausedIdleFactor
+	}
+	if now.Sub(*lastKey) > timeout {
+		return false
+	}
+	a.CheckWPM() // speed limit is checked on every tick, like on screen update
+	return !a.Over()
+}
+
+func (s *Service) publish(p Progress) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	s.nc.Publish(s.ResultsSubject+"."+p.Typist, data)
+}
+
+// Progress returns progress of session, for typist
+func (a *App) Progress(typist string) Progress {
+	errors := 0
+	for _, e := range a.Errors {
+		errors += e
+	}
+	now := a.Clock.Now()
+	p := Progress{
+		Typist:  typist,
+		Typed:   a.InputPosition,
+		Length:  len(a.Text),
+		Errors:  errors,
+		Elapsed: a.elapsed(now),
+		Paused:  a.Paused,
+	}
+	if p.Elapsed > 0 {
+		p.WPM = float64(a.InputPosition) * wordsPerChar / p.Elapsed * 60
+	}
+	return p
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/nats-io/nats.go"
+)
+
+// TextSource provides text for endless sessions. When typist gets close
+// to the end of text, App asks source for next chunk.
+type TextSource interface {
+	// Next returns next chunk of text, or empty string if there is nothing yet
+	Next() (string, error)
+}
+
+// TextSourceFunc is an adapter to use ordinary function as TextSource
+type TextSourceFunc func() (string, error)
+
+func (f TextSourceFunc) Next() (string, error) {
+	return f()
+}
+
+const (
+	// StreamLookahead is number of characters that are kept ahead of cursor
+	StreamLookahead = 300
+	// StreamWindow is number of typed characters kept in memory. When more is
+	// typed, older text is flushed to stats with App.Flush.
+	StreamWindow = 3000
+	// streamKeep is number of typed characters left after flush, for speed computation
+	streamKeep = WPMWindow * 2
+	// maximal number of chunks requested from source in one refill
+	maxChunks = 10
+	// number of received chunks waiting to be typed, more are dropped
+	textChunks = 100
+)
+
+// TextGap is inserted in text where chunks received from NATS were dropped,
+// so typist sees that some text is missing
+const TextGap = "... "
+
+// Endless returns true if session has text source, and could go forever
+func (a *App) Endless() bool {
+	return a.Source != nil
+}
+
+// refill requests text from source till there is StreamLookahead characters
+// ahead of cursor. When source fails, session becomes finite.
+func (a *App) refill() {
+	for i := 0; a.Source != nil && i < maxChunks; i++ {
+		if len(a.Text)-a.InputPosition >= StreamLookahead {
+			return
+		}
+		chunk, err := a.Source.Next()
+		if err != nil {
+			a.SourceErr = err
+			a.Source = nil
+			return
+		}
+		if chunk == "" {
+			return
+		}
+		a.extend([]rune(chunk))
+	}
+}
+
+// extend appends chunk to text, separating them with space when needed
+func (a *App) extend(chunk []rune) {
+	if n := len(a.Text); n > 0 && !unicode.IsSpace(a.Text[n-1]) && !unicode.IsSpace(chunk[0]) {
+		chunk = append([]rune{' '}, chunk...)
+	}
+	a.Text = append(a.Text, chunk...)
+	a.Timeline = append(a.Timeline, make([]float64, len(chunk))...)
+	a.Errors = append(a.Errors, make([]int, len(chunk))...)
+	a.Mistyped = append(a.Mistyped, make([]bool, len(chunk))...)
+	a.Typists = append(a.Typists, make([]string, len(chunk))...)
+}
+
+// flush passes old typed text to Flush callback and removes it from memory,
+// so endless session does not grow forever. Tests are not flushed, so their
+// result could be computed from the whole session.
+func (a *App) flush() {
+	if a.Flush == nil || a.Test() != "" || a.InputPosition <= StreamWindow {
+		return
+	}
+	n := a.InputPosition - streamKeep
+	session := a.Session(n)
+	_, idle := a.Stats.IdleTime(session.Timeline)
+	// trigrams on the border of flushed text are lost, that is not much
+	a.Text = append([]rune(nil), a.Text[n:]...)
+	a.Timeline = append([]float64(nil), a.Timeline[n:]...)
+	a.Errors = append([]int(nil), a.Errors[n:]...)
+	a.Mistyped = append([]bool(nil), a.Mistyped[n:]...)
+	a.Typists = append([]string(nil), a.Typists[n:]...)
+	a.InputPosition -= n
+	a.flushedChars += n
+	a.flushedTime += session.Timeline[n-1]
+	a.flushedIdle += idle
+	if a.flushedTypists == nil {
+		a.flushedTypists = make(map[string]int)
+	}
+	for _, t := range session.Typists {
+		a.flushedTypists[t]++
+	}
+	a.Flush(session)
+}
+
+// Session returns first n typed characters of text (that were not flushed yet)
+// as stats session, with timeline starting from beginning of that text.
+func (a *App) Session(n int) stats.Session {
+	timeline := make([]float64, n)
+	for i := range timeline {
+		timeline[i] = a.Timeline[i] - a.flushedTime
+	}
+	// pauses start at points of timeline, and last End - Start seconds
+	offset := a.flushedTime // time from start of session till start of text, with pauses
+	pauses := make([]stats.PauseInterval, 0)
+	for _, p := range a.Pauses {
+		if p.Start < a.flushedTime {
+			offset += p.End - p.Start
+			continue
+		}
+		if n == 0 || p.Start < a.Timeline[n-1] {
+			pauses = append(pauses, stats.PauseInterval{
+				Start: p.Start - a.flushedTime,
+				End:   p.End - a.flushedTime,
+			})
+		}
+	}
+	start := a.StartedAt
+	if !start.IsZero() {
+		start = start.Add(time.Duration(offset * float64(time.Second)))
+	}
+	return stats.Session{
+		Start:    start,
+		Text:     a.Text[:n],
+		Timeline: timeline,
+		Errors:   a.Errors[:n],
+		Mistyped: a.Mistyped[:n],
+		Typists:  a.Typists[:n],
+		Pauses:   pauses,
+	}
+}
+
+// NATSTextSource receives text to type from NATS subject,
+// where it is published by some coordinator
+type NATSTextSource struct {
+	nc      *nats.Conn
+	sub     *nats.Subscription
+	chunks  chan string
+	dropped uint64
+	// chunk was dropped, next one starts with TextGap. Only NATS callback uses it.
+	gap bool
+}
+
+// NewNATSTextSource subscribes to subject on NATS server with given URL
+func NewNATSTextSource(url, subject string) (*NATSTextSource, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	s := &NATSTextSource{nc: nc, chunks: make(chan string, textChunks)}
+	s.sub, err = nc.Subscribe(subject, func(msg *nats.Msg) {
+		// do not block NATS client when typist is far behind coordinator
+		chunk := string(msg.Data)
+		if s.gap {
+			chunk = TextGap + chunk
+		}
+		select {
+		case s.chunks <- chunk:
+			s.gap = false
+		default:
+			s.gap = true
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	})
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Next returns chunk of text received, without waiting when there is none
+func (s *NATSTextSource) Next() (string, error) {
+	select {
+	case chunk := <-s.chunks:
+		return chunk, nil
+	default:
+		return "", nil
+	}
+}
+
+// Dropped returns number of chunks that were dropped because too many were waiting
+func (s *NATSTextSource) Dropped() int {
+	return int(atomic.LoadUint64(&s.dropped))
+}
+
+func (s *NATSTextSource) Close() {
+	s.sub.Unsubscribe()
+	s.nc.Close()
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Test returns name of test mode of session, like "time:60s+sudden-death",
+// or empty string if session ends only when text is typed
+func (a *App) Test() string {
+	parts := make([]string, 0)
+	if a.TimeLimit > 0 {
+		parts = append(parts, fmt.Sprintf("time:%s", a.TimeLimit))
+	}
+	if a.WordLimit > 0 {
+		parts = append(parts, fmt.Sprintf("words:%d", a.WordLimit))
+	}
+	if a.SuddenDeath {
+		parts = append(parts, "sudden-death")
+	}
+	return strings.Join(parts, "+")
+}
+
+// WordsTyped counts words which last character was typed
+func (a *App) WordsTyped() int {
+	n := 0
+	for i := 0; i < a.InputPosition; i++ {
+		wordEnd := i+1 == len(a.Text) || unicode.IsSpace(a.Text[i+1])
+		if wordEnd && !unicode.IsSpace(a.Text[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// timeLeft returns seconds till end of timed test
+func (a *App) timeLeft(now time.Time) float64 {
+	left := a.TimeLimit.Seconds() - a.elapsed(now)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// testOver returns true when time, or words of test ended, or typist died
+func (a *App) testOver(now time.Time) bool {
+	if a.Died {
+		return true
+	}
+	if a.TimeLimit > 0 && !a.StartedAt.IsZero() && a.timeLeft(now) <= 0 {
+		a.TimeUp = true
+		return true
+	}
+	return a.WordLimit > 0 && a.WordsTyped() >= a.WordLimit
+}
+
+// Duration returns duration of session in seconds, without pauses.
+// For timed test that ended by time it is the time limit.
+func (a *App) Duration() float64 {
+	if a.TimeUp {
+		return a.TimeLimit.Seconds()
+	}
+	if a.InputPosition == 0 {
+		return 0
+	}
+	return a.Timeline[a.InputPosition-1]
+}
@@ -0,0 +1,19 @@
+package app
+
+import "testing"
+
+// StartTestServer starts embedded server on random port, with streams kept in
+// temporary directory, for tests of packages that talk to NATS. Server is shut
+// down when test ends.
+func StartTestServer(t testing.TB) *Server {
+	t.Helper()
+	opts := DefaultServerOptions()
+	opts.Port = -1 // random
+	opts.StoreDir = t.TempDir()
+	srv, err := StartServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
@@ -0,0 +1,266 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+// InputSource delivers key events (and control commands) to session
+type InputSource interface {
+	// Start begins to push events to queue, and returns without waiting.
+	// Pushing should not wait, so slow trainer does not block the source.
+	Start(events *EventQueue) error
+	// Close stops sending events
+	Close()
+	// Status tells state of connection, and how much input lags
+	Status() InputStatus
+}
+
+// Kinds of input, selected with --input flag
+const (
+	// InputLocal is keyboard of terminal where trainer runs, without NATS
+	InputLocal = "local"
+	// InputNATS receives keys with core NATS subscription
+	InputNATS = "nats"
+	// InputJetStream receives keys from JetStream stream with push consumer
+	InputJetStream = "jetstream"
+	// InputJetStreamPull fetches keys from JetStream durable pull consumer
+	InputJetStreamPull = "jetstream-pull"
+)
+
+var inputKinds = []string{InputLocal, InputNATS, InputJetStream, InputJetStreamPull}
+
+// InputKinds returns names of all kinds of input
+func InputKinds() []string {
+	return inputKinds
+}
+
+// DefaultURL of NATS server to receive keys from
+const DefaultURL = nats.DefaultURL
+
+// JetStream stream and durable pull consumer keys are received from
+const (
+	StreamName   = "EVENTS"
+	ConsumerName = "pull"
+)
+
+// DefaultSubject returns subject publisher sends keys to for given kind of input
+func DefaultSubject(kind string) string {
+	if kind == InputJetStream || kind == InputJetStreamPull {
+		return "events.key"
+	}
+	return "foo.bar"
+}
+
+// NewInput creates input source of given kind. Terminal keyboard is always
+// used, so for local input there is no additional source and nil is returned.
+// Clocks of publishers are synced with requests to clockSubject, when it is not empty.
+func NewInput(kind, url, subject, clockSubject string) (InputSource, error) {
+	if subject == "" {
+		subject = DefaultSubject(kind)
+	}
+	switch kind {
+	case InputLocal:
+		return nil, nil
+	case InputNATS:
+		return &natsInput{link: link{clockSubject: clockSubject}, url: url, subject: subject}, nil
+	case InputJetStream:
+		return &jetStreamInput{link: link{clockSubject: clockSubject}, url: url, subject: subject}, nil
+	case InputJetStreamPull:
+		return &jetStreamPullInput{link: link{clockSubject: clockSubject}, url: url, subject: subject}, nil
+	}
+	return nil, fmt.Errorf(
+		"unknown input %q, available: %s", kind, strings.Join(inputKinds, ", "),
+	)
+}
+
+// terminalInput is keyboard (and resizes) of terminal where session is shown.
+// Events are stamped with time of clock when they are captured.
+type terminalInput struct {
+	scr   tcell.Screen
+	clock Clock
+}
+
+func (t terminalInput) Start(events *EventQueue) error {
+	go func() {
+		for {
+			ev := t.scr.PollEvent()
+			if ev == nil { // screen finalized
+				return
+			}
+			events.pushLocal(&sourcedEvent{Event: ev, source: SourceLocal, captured: t.clock.Now()})
+		}
+	}()
+	return nil
+}
+
+func (t terminalInput) Close() {}
+
+func (t terminalInput) Status() InputStatus {
+	return InputStatus{State: LinkConnected}
+}
+
+// natsInput receives EventMsg with core NATS subscription
+type natsInput struct {
+	link
+	url, subject string
+	ec           *nats.EncodedConn
+	sub          *nats.Subscription
+}
+
+func (n *natsInput) Start(events *EventQueue) error {
+	nc, err := n.connect(n.url)
+	if err != nil {
+		return err
+	}
+	n.ec, err = nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	if err != nil {
+		nc.Close()
+		return err
+	}
+	if n.sub, err = n.ec.Subscribe(n.subject, func(msg EventMsg) {
+		n.received()
+		events.Push(n.clocks.correct(msg.Event()))
+	}); err != nil {
+		n.ec.Close()
+		return err
+	}
+	return nil
+}
+
+func (n *natsInput) Close() {
+	n.stop()
+	n.ec.Close()
+}
+
+func (n *natsInput) Status() InputStatus {
+	st := n.link.Status()
+	if n.sub != nil {
+		if msgs, _, err := n.sub.Pending(); err == nil {
+			st.Pending = msgs
+		}
+	}
+	return st
+}
+
+// jetStreamInput receives EventMsg from JetStream with ephemeral push consumer,
+// which gets only keys published after start
+type jetStreamInput struct {
+	link
+	url, subject string
+	nc           *nats.Conn
+}
+
+func (j *jetStreamInput) Start(events *EventQueue) error {
+	var err error
+	if j.nc, err = j.connect(j.url); err != nil {
+		return err
+	}
+	js, err := j.nc.JetStream()
+	if err != nil {
+		j.nc.Close()
+		return err
+	}
+	sub, err := js.Subscribe(j.subject, func(msg *nats.Msg) {
+		j.received()
+		msg.Ack()
+		if ev := decodeEvent(msg.Data); ev != nil {
+			events.Push(j.clocks.correct(ev))
+		}
+	}, nats.BindStream(StreamName), nats.DeliverNew())
+	if err != nil {
+		j.nc.Close()
+		return err
+	}
+	j.watchConsumer(sub)
+	return nil
+}
+
+func (j *jetStreamInput) Close() {
+	j.stop()
+	j.nc.Drain()
+}
+
+// Waits between failed fetches from pull consumer, doubled after each failure
+const (
+	minFetchBackoff = 100 * time.Millisecond
+	maxFetchBackoff = 5 * time.Second
+)
+
+// jetStreamPullInput fetches EventMsg from durable JetStream pull consumer
+type jetStreamPullInput struct {
+	link
+	url, subject string
+	nc           *nats.Conn
+}
+
+func (j *jetStreamPullInput) Start(events *EventQueue) error {
+	var err error
+	if j.nc, err = j.connect(j.url); err != nil {
+		return err
+	}
+	js, err := j.nc.JetStream()
+	if err != nil {
+		j.nc.Close()
+		return err
+	}
+	sub, err := js.PullSubscribe(j.subject, ConsumerName, nats.BindStream(StreamName))
+	if err != nil {
+		j.nc.Close()
+		return err
+	}
+	j.watchConsumer(sub)
+	go func() {
+		backoff := minFetchBackoff
+		failed := false
+		for {
+			msgs, err := sub.Fetch(1)
+			if err != nil && err != nats.ErrTimeout {
+				// connection could be lost for a while, wait and try again till closed
+				j.setState(LinkRetrying)
+				failed = true
+				select {
+				case <-j.done:
+					return
+				case <-time.After(backoff):
+				}
+				if backoff *= 2; backoff > maxFetchBackoff {
+					backoff = maxFetchBackoff
+				}
+				continue
+			}
+			if failed {
+				j.setState(LinkConnected)
+				failed = false
+			}
+			backoff = minFetchBackoff
+			for _, msg := range msgs {
+				j.received()
+				msg.Ack()
+				if ev := decodeEvent(msg.Data); ev != nil {
+					events.Push(j.clocks.correct(ev))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (j *jetStreamPullInput) Close() {
+	j.stop()
+	j.nc.Drain()
+}
+
+// decodeEvent converts JSON encoded EventMsg to event, or returns nil if it is malformed
+func decodeEvent(data []byte) tcell.Event {
+	var msg EventMsg
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+	return msg.Event()
+}
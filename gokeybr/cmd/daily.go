@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/spf13/cobra"
+)
+
+var dailyLength, dailyItems int
+
+var dailyCmd = &cobra.Command{
+	Use:   "daily [flags]",
+	Short: "train weak character combinations that are due for review today",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dailyLength < stats.MinSessionLength {
+			fmt.Printf("Sequence should be at least %d characters long\n", stats.MinSessionLength)
+			return
+		}
+		drill, err := stats.DailyTraining(testLength(dailyLength), dailyItems)
+		fatal(err)
+		a, err := newApp(drill.Text)
+		fatal(err)
+
+		err = a.Run()
+		fatal(err)
+
+		saveStats(a, true)
+
+		report, err := drill.Review(a.Text[:a.InputPosition])
+		fatal(err)
+		fmt.Println(report)
+	},
+}
+
+func init() {
+	dailyCmd.Flags().IntVarP(&dailyLength, "length", "l", 150,
+		"Minimal lenght in characters of generated text (default 150)",
+	)
+	dailyCmd.Flags().IntVarP(&dailyItems, "number", "n", 5,
+		"Maximal number of character combinations to review (default 5)",
+	)
+	rootCmd.AddCommand(dailyCmd)
+}
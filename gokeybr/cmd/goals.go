@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/spf13/cobra"
+)
+
+var goalMode string
+
+var goalsCmd = &cobra.Command{
+	Use:   "goals",
+	Short: "show progress of your typing goals",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := stats.GoalsReport()
+		fatal(err)
+		fmt.Println(report)
+	},
+}
+
+var goalsAddCmd = &cobra.Command{
+	Use:   fmt.Sprintf("add [flags] (%s) target", strings.Join(stats.GoalKinds(), "|")),
+	Short: "add new goal",
+	Long: `Add new goal, for example:
+
+    gokeybr goals add wpm 70 --mode random   - reach 70 wpm in random mode
+    gokeybr goals add minutes 15             - train 15 minutes per day
+    gokeybr goals add accuracy 97            - hit at least 97% of keys correctly`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		target, err := strconv.ParseFloat(args[1], 64)
+		fatal(err)
+		fatal(stats.AddGoal(args[0], target, goalMode))
+	},
+}
+
+var goalsRemoveCmd = &cobra.Command{
+	Use:     "remove [number of goal in list]",
+	Aliases: []string{"rm"},
+	Short:   "remove goal",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		n, err := strconv.Atoi(args[0])
+		fatal(err)
+		fatal(stats.RemoveGoal(n))
+	},
+}
+
+func init() {
+	goalsAddCmd.Flags().StringVar(&goalMode, "mode", "",
+		"Count only sessions of this mode (random, weakest, words, text, daily)",
+	)
+	goalsCmd.AddCommand(goalsAddCmd)
+	goalsCmd.AddCommand(goalsRemoveCmd)
+	rootCmd.AddCommand(goalsCmd)
+}
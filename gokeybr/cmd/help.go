@@ -0,0 +1,95 @@
+package cmd
+
+const Help = `
+gokeybr is a touch-typing training program
+
+Examples: 
+
+   How to run to train your bash commands typing speed, customized for your commands:
+
+       history | gokeybr text -
+
+   Or train to type random quote, like on typeracer:
+   
+       fortune | gokeybr text -
+
+   Or train to type on randomly generated sequence, like on keybr.com:
+
+       gokeybr markov
+
+   Or train weak character combinations that are due for review today (spaced repetition):
+
+       gokeybr daily
+
+   Or train without end, getting new text when the end is near (random, weakest and words modes):
+
+       gokeybr weakest --endless
+
+   Or type text published by some coordinator to NATS subject:
+
+       gokeybr stream --text-subject gokeybr.text
+
+   Endless sessions save stats while you type, so weakest mode picks new weak spots on the go.
+
+Key bindings:
+
+   ESC      quit
+   Ctrl+P   pause / continue
+   Ctrl+Backspace, Ctrl+W   remove typed word (with --policy word or free)
+
+Inputs (--input), keys typed in terminal are always received:
+   nats             core NATS subscription to --subject (default foo.bar)
+   jetstream        push consumer of JetStream stream EVENTS (subject events.key)
+   jetstream-pull   durable pull consumer "pull" of JetStream stream EVENTS
+   local            no NATS, only keyboard
+
+   No NATS server installed? Run "gokeybr serve" in other terminal, or add --embedded
+   to run server with JetStream (stream EVENTS, files in ~/.gokeybr/js) inside trainer.
+   --host, --port, --ws-port and --store configure both.
+
+Input policies (--input-policy), Esc from remote keystream never quits:
+   merged   keys from everyone are typed (default)
+   remote   only remote keys are typed, keyboard is used only to quit and pause
+   local    remote keys are ignored
+   pair     local and remote typists take turns, passing typing after each word
+
+Error policies (--policy):
+   correct   every wrong character should be removed with Backspace before continuing (default)
+   stop      wrong keys are ignored, cursor does not move until right key is hit
+   free      wrong characters move cursor too, and are shown in red
+   word      like correct, but Backspace removes correctly typed characters too
+
+Tests (could be combined, and used with any mode):
+   --time 60        session ends after 60 seconds
+   --words 50       session ends after 50 words typed
+   --sudden-death   session ends at first wrong key
+
+   Result of test (net wpm, accuracy) is saved in session log, best and last results
+   of each test are shown by "gokeybr stats".
+
+Files:
+	gokeybr stores log of your training sessions in file ~/.gokeybr/sessions_log.jsonl.
+	Each line in that file contains timestamp, text, and timeline of one session.
+	Timeline is list of values of seconds each character in text was typed.
+	Last value in timeline will give session duration.
+
+	For generated exercises it also contains seed, so you (or teammate with the same
+	stats.json) could repeat exercise by passing it with --seed flag.
+
+	Purpose of this file is to be able to compute more detailed stats later.
+
+	
+	~/.gokeybr/stats.json is used to store general statistics used to generate training sessions.
+	For each trigram it keeps last WindowCapacity typing times (30 by default), and their
+	moving average, where weight of each time halves every HalfLife (10 by default) newer times.
+	Both could be changed by editing that file.
+
+	~/.gokeybr/schedule.json keeps review schedule of character combinations trained by "daily".
+
+	When --profile other than "default" is given, all those files are stored in
+	~/.gokeybr/profiles/<profile name>/ instead. Use "gokeybr profile" to manage profiles.
+
+	Gaps between keystrokes longer than 8 median gaps of the session (but at least 1 second)
+	are counted as pauses and excluded from speed and trigram statistics. Set PauseThreshold
+	(seconds) or PauseFactor in stats.json to change that.
+`
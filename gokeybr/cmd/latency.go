@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/spf13/cobra"
+)
+
+var latencySessions int
+
+var latencyCmd = &cobra.Command{
+	Use:   "latency",
+	Short: "show latency of keys received from NATS in last sessions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		text, err := stats.LatencyReport(latencySessions)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(text)
+	},
+}
+
+func init() {
+	latencyCmd.Flags().IntVarP(&latencySessions, "number", "n", 10,
+		"Number of last sessions to show",
+	)
+	rootCmd.AddCommand(latencyCmd)
+}
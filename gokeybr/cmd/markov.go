@@ -9,6 +9,7 @@ import (
 )
 
 var markovLength int
+var markovOrder int
 
 var markovCmd = &cobra.Command{
 	Use:     "random [flags]",
@@ -19,13 +20,16 @@ var markovCmd = &cobra.Command{
 		if markovLength < stats.MinSessionLength {
 			fmt.Printf("Sequence should be at least %d characters long\n", stats.MinSessionLength)
 		}
-		text, err := stats.RandomTraining(markovLength)
+		rng := newRand()
+		text, err := stats.RandomTraining(testLength(markovLength), markovOrder, rng)
 		fatal(err)
-		a, err := app.New(text)
+		a, err := newApp(text)
 		fatal(err)
-		a.Zen = zen
-		a.Mute = mute
-		a.MinSpeed = minSpeed
+		if endless {
+			stream(a, true, app.TextSourceFunc(func() (string, error) {
+				return stats.RandomTraining(markovLength, markovOrder, rng)
+			}))
+		}
 
 		err = a.Run()
 		fatal(err)
@@ -38,5 +42,9 @@ func init() {
 	markovCmd.Flags().IntVarP(&markovLength, "length", "l", 100,
 		"Minimal lenght in characters of generated text (default 100)",
 	)
+	markovCmd.Flags().IntVarP(&markovOrder, "order", "r", stats.DefaultMarkovOrder,
+		"Size of character sequences in Markov chain: 2, 3 or 4",
+	)
+	addSeedFlag(markovCmd)
 	rootCmd.AddCommand(markovCmd)
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/bunyk/gokeybr/phrase"
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/spf13/cobra"
+)
+
+// selectProfile switches to profile given by --profile or --profile-from flags
+func selectProfile() error {
+	name := profile
+	switch profileFrom {
+	case "":
+	case "subject":
+		tokens := strings.Split(subject, ".")
+		for _, t := range tokens {
+			if t == "*" || t == ">" {
+				return fmt.Errorf("could not name profile after wildcard subject %q", subject)
+			}
+		}
+		name = tokens[len(tokens)-1]
+	case "user":
+		u, err := url.Parse(natsURL)
+		if err != nil {
+			return err
+		}
+		if u.User == nil || u.User.Username() == "" {
+			return fmt.Errorf("NATS URL %q has no user to name profile after", natsURL)
+		}
+		name = u.User.Username()
+	default:
+		return fmt.Errorf("--profile-from should be \"subject\" or \"user\", got %q", profileFrom)
+	}
+	if err := fs.ValidateProfileName(name); err != nil {
+		return err
+	}
+	fs.Profile = name
+	return nil
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "manage profiles of different typists",
+	Long: `Each profile has separate stats, sessions log, file progress, goals and review schedule.
+Select profile with --profile flag, or with --profile-from to name it after NATS subject or user.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "list profiles",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := fs.Profiles()
+		fatal(err)
+		for _, p := range profiles {
+			mark := " "
+			if p == fs.Profile {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\n", mark, p)
+		}
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "create new profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fatal(fs.CreateProfile(args[0]))
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:     "delete [name]",
+	Aliases: []string{"rm"},
+	Short:   "delete profile with all its stats",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fatal(fs.DeleteProfile(args[0]))
+	},
+}
+
+var profileMergeCmd = &cobra.Command{
+	Use:   "merge [from] [into]",
+	Short: "add stats of one profile to another",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		fatal(fs.ValidateProfileName(args[1]))
+		restore := fs.UseProfile(args[1])
+		defer restore()
+		fatal(stats.MergeProfile(args[0]))
+		fatal(phrase.MergeProgress(args[0]))
+		fmt.Printf("Merged profile %s into %s\n", args[0], args[1])
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+	profileCmd.AddCommand(profileMergeCmd)
+	rootCmd.AddCommand(profileCmd)
+}
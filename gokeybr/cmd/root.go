@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/bunyk/gokeybr/stats"
+)
+
+var zen bool
+var mute bool
+var minSpeed int
+var seed int64
+var scoring string
+var speedOfLight float64
+
+// name of command that started session
+var mode string
+
+var input, natsURL, subject, clockSubject string
+var profile, profileFrom string
+var policy string
+var inputPolicy string
+var showLatency bool
+
+// run NATS server in the same process, instead of connecting to --url
+var embedded bool
+
+// test modes
+var timeLimit, wordLimit int
+var suddenDeath bool
+
+// endless sessions get new text generated when the end is near
+var endless bool
+var flushErr error
+var rootCmd = &cobra.Command{
+	Use:  "gokeybr",
+	Long: Help,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		mode = cmd.Name()
+		if subject == "" {
+			subject = app.DefaultSubject(input)
+		}
+		fatal(stats.SetScoring(scoring, speedOfLight))
+		fatal(selectProfile())
+		if embedded && cmd != serveCmd {
+			fatal(startEmbedded())
+		}
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		stopEmbedded()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+// newApp creates typing session for text, configured by global flags
+func newApp(text string) (*app.App, error) {
+	a, err := app.New(text)
+	if err != nil {
+		return a, err
+	}
+	if err = configure(a); err != nil {
+		return a, err
+	}
+	if a.Input, err = app.NewInput(input, natsURL, subject, clockSubject); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// configure sets options of session from global flags, except of input
+func configure(a *app.App) error {
+	var err error
+	a.Zen = zen
+	a.Mute = mute
+	a.MinSpeed = minSpeed
+	if a.Policy, err = app.ParseErrorPolicy(policy); err != nil {
+		return err
+	}
+	if a.InputPolicy, err = app.ParseInputPolicy(inputPolicy); err != nil {
+		return err
+	}
+	a.ShowLatency = showLatency
+	a.TimeLimit = time.Duration(timeLimit) * time.Second
+	a.WordLimit = wordLimit
+	a.SuddenDeath = suddenDeath
+	return nil
+}
+
+// testLength returns length of text in characters to generate, so it will be
+// enough for timed or word count test
+func testLength(length int) int {
+	return storeTestLength(stats.CurrentStore(), length)
+}
+
+// storeTestLength is like testLength, but for speed of typist with given stats
+func storeTestLength(store stats.Store, length int) int {
+	const charsPerWord = 6 // 5 characters + space
+	if l := wordLimit * charsPerWord; l > length {
+		length = l
+	}
+	// Generate for speed twice faster than average, to not run out of text
+	wpm := store.AverageWPM() * 2
+	if l := int(wpm * charsPerWord * float64(timeLimit) / 60); l > length {
+		length = l
+	}
+	return length
+}
+
+// testWords is like testLength, but in words
+func testWords(n int) int {
+	const charsPerWord = 6
+	if l := testLength(0) / charsPerWord; l > n {
+		n = l
+	}
+	return n
+}
+
+// describeSession adds to session info on how it was started
+func describeSession(session *stats.Session, isTraining bool) {
+	session.Training = isTraining
+	session.Seed = seed
+	session.Mode = mode
+}
+
+func saveStats(a *app.App, isTraining bool) {
+	fmt.Println(a.Summary())
+	if a.SourceErr != nil {
+		fmt.Println("Text source failed:", a.SourceErr)
+	}
+	if flushErr != nil {
+		fmt.Println("Failed to save stats during session:", flushErr)
+	}
+	session := a.Session(a.InputPosition)
+	describeSession(&session, isTraining)
+	session.Test = a.Test()
+	session.Duration = a.Duration()
+	session.Latency = a.Latency
+	if a.Input != nil {
+		session.Clocks = a.Input.Status().Clocks
+	}
+	if session.Test != "" {
+		if a.Died {
+			fmt.Println("Died at first wrong key")
+		}
+		fmt.Println(stats.NewTestResult(session, session.Duration))
+	}
+	if err := stats.SaveSession(session); err != nil {
+		fmt.Println(err)
+	}
+	report, err := stats.GoalsReport()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(report)
+}
+
+// stream makes session endless, with text produced by source, and typed
+// text saved to stats while session goes on
+func stream(a *app.App, isTraining bool, source app.TextSource) {
+	a.Source = source
+	a.Flush = func(session stats.Session) {
+		describeSession(&session, isTraining)
+		if err := stats.SaveSession(session); err != nil {
+			flushErr = err
+		}
+	}
+}
+
+// addSeedFlag adds --seed flag to command that generates exercises
+func addSeedFlag(c *cobra.Command) {
+	c.Flags().Int64Var(&seed, "seed", 0,
+		"Seed for exercise generation, to repeat exercise typed before (default - random)",
+	)
+}
+
+// newRand returns source of randomness for exercise generation, seeded with
+// --seed flag value, or current time. Seed is printed, and saved in session log.
+func newRand() *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Generating exercise with seed %d\n", seed)
+	return rand.New(rand.NewSource(seed))
+}
+
+func fatal(err error) {
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func Execute() {
+	pf := rootCmd.PersistentFlags()
+	pf.BoolVarP(&zen, "zen", "z", false, "run training session in \"zen mode\" (minimal screen output)")
+	pf.BoolVarP(&mute, "mute", "m", false, "Do not produce sound when wrong key is hit")
+	pf.IntVarP(&minSpeed, "min-speed", "s", 0, "Minimal speed limit in WPM")
+	pf.StringVar(&policy, "policy", "correct", fmt.Sprintf(
+		"What happens on wrong key: %s (see help)", strings.Join(app.ErrorPolicies(), ", "),
+	))
+	pf.IntVar(&timeLimit, "time", 0, "Timed test: session ends after given number of seconds")
+	pf.IntVar(&wordLimit, "words", 0, "Word count test: session ends after given number of words")
+	pf.BoolVar(&endless, "endless", false,
+		"Generate more text when the end is near, so session goes on till Esc (random, weakest, words)",
+	)
+	pf.BoolVar(&suddenDeath, "sudden-death", false, "Session ends at first wrong key")
+	pf.StringVar(&input, "input", app.InputNATS, fmt.Sprintf(
+		"Where to receive keys from besides keyboard: %s", strings.Join(app.InputKinds(), ", "),
+	))
+	pf.StringVar(&inputPolicy, "input-policy", "merged", fmt.Sprintf(
+		"Whose keys are typed: %s (see help)", strings.Join(app.InputPolicies(), ", "),
+	))
+	pf.BoolVar(&showLatency, "latency", false, "Show latency of remote keys in status line")
+	pf.StringVar(&natsURL, "url", app.DefaultURL, "NATS server URL to receive keys from")
+	pf.BoolVar(&embedded, "embedded", false,
+		"Run NATS server with JetStream inside trainer (like \"serve\" does), instead of connecting to --url",
+	)
+	pf.StringVar(&serverOpts.Host, "host", serverOpts.Host, "Address NATS server of serve or --embedded listens on")
+	pf.IntVar(&serverOpts.Port, "port", serverOpts.Port, "Port NATS server of serve or --embedded listens on")
+	pf.IntVar(&serverOpts.WebsocketPort, "ws-port", 0,
+		"Port NATS server of serve or --embedded listens on for websocket clients (0 - do not listen)",
+	)
+	pf.StringVar(&serverOpts.StoreDir, "store", serverOpts.StoreDir,
+		"Directory NATS server of serve or --embedded stores JetStream streams in",
+	)
+	pf.StringVar(&subject, "subject", "",
+		"NATS subject to receive keys from (default foo.bar, or events.key for JetStream input)",
+	)
+	pf.StringVar(&clockSubject, "clock-subject", app.DefaultClockSubject,
+		"NATS subject to ask publishers for their time, to correct for clock difference (\"\" - do not ask)",
+	)
+	pf.StringVarP(&profile, "profile", "p", fs.DefaultProfile, "Profile to store your stats in")
+	pf.StringVar(&profileFrom, "profile-from", "",
+		"Use profile named after last token of NATS subject (\"subject\") or NATS user (\"user\")",
+	)
+	pf.StringVar(&scoring, "scoring", "effort", fmt.Sprintf(
+		"Model used to choose what to train: %s", strings.Join(stats.ScoringModels(), ", "),
+	))
+	pf.Float64Var(&speedOfLight, "speed-of-light", 150,
+		"Typing speed in WPM considered unreachable, used by scoring models",
+	)
+	fatal(rootCmd.Execute())
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/spf13/cobra"
+)
+
+// options of NATS server started by "serve" command, or by --embedded flag
+var serverOpts = app.DefaultServerOptions()
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [flags]",
+	Short: "run NATS server with JetStream, for publishers and trainers to connect to",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		serverOpts.Log = true
+		srv, err := app.StartServer(serverOpts)
+		fatal(err)
+		fmt.Println("NATS server is listening on", srv.ClientURL())
+		if serverOpts.WebsocketPort != 0 {
+			fmt.Printf("Websocket clients could connect to ws://%s:%d\n", serverOpts.Host, serverOpts.WebsocketPort)
+		}
+		fmt.Println("JetStream files are stored in", serverOpts.StoreDir)
+		fmt.Println("Press Ctrl+C to stop")
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		srv.Shutdown()
+	},
+}
+
+// embeddedServer is started before command when --embedded flag is given
+var embeddedServer *app.Server
+
+// startEmbedded starts NATS server in the same process, and makes session connect to it
+func startEmbedded() error {
+	srv, err := app.StartServer(serverOpts)
+	if err != nil {
+		return err
+	}
+	embeddedServer = srv
+	natsURL = srv.ClientURL()
+	return nil
+}
+
+func stopEmbedded() {
+	if embeddedServer != nil {
+		embeddedServer.Shutdown()
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/bunyk/gokeybr/phrase"
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/spf13/cobra"
+)
+
+var keysSubject, resultsSubject, serviceWordsFile string
+var serviceLength, serviceIdle int
+
+var serviceCmd = &cobra.Command{
+	Use:   "service [flags]",
+	Short: "train many typists at once without terminal, receiving their keys from NATS",
+	Long: `Service receives keys of typists from subjects matching --keys, last token of
+subject names typist and profile their stats are saved to. First key of typist,
+or {"Control": "start"}, starts session. Text of session, progress, and summary when
+session ends are published to --results subject with name of typist appended.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var mu sync.Mutex
+		training := make(map[string]bool) // whether session of typist is training
+		s := &app.Service{
+			URL:            natsURL,
+			Subject:        keysSubject,
+			ResultsSubject: resultsSubject,
+			IdleTimeout:    time.Duration(serviceIdle) * time.Second,
+			NewSession: func(typist string) (*app.App, error) {
+				if err := fs.ValidateProfileName(typist); err != nil {
+					return nil, err
+				}
+				store := stats.ProfileStore(typist)
+				text, isTraining, err := serviceText(store)
+				if err != nil {
+					return nil, err
+				}
+				mu.Lock()
+				training[typist] = isTraining
+				mu.Unlock()
+				a := app.NewSession(text, app.SystemClock)
+				a.Stats = store
+				return a, configure(a)
+			},
+			Save: func(typist string, a *app.App) error {
+				fmt.Printf("%s: %s\n", typist, a.Summary())
+				mu.Lock()
+				isTraining := training[typist]
+				delete(training, typist)
+				mu.Unlock()
+				session := a.Session(a.InputPosition)
+				describeSession(&session, isTraining)
+				session.Test = a.Test()
+				session.Duration = a.Duration()
+				session.Latency = a.Latency
+				return a.Stats.SaveSession(session)
+			},
+			OnError: func(typist string, err error) {
+				fmt.Printf("%s: %v\n", typist, err)
+			},
+		}
+		fatal(s.Start())
+		fmt.Printf("Receiving keys from %s, publishing results to %s.<typist>\n", keysSubject, resultsSubject)
+		fmt.Println("Press Ctrl+C to stop")
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		s.Stop()
+	},
+}
+
+// serviceText generates text for session of typist: random text based on
+// stats, or random words when there are not enough stats yet
+func serviceText(store stats.Store) (text string, training bool, err error) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	length := storeTestLength(store, serviceLength)
+	text, err = store.RandomTraining(length, stats.DefaultMarkovOrder, rng)
+	if err == nil {
+		return text, true, nil
+	}
+	const charsPerWord = 6
+	text, err = phrase.Words(serviceWordsFile, length/charsPerWord, rng)
+	return text, false, err
+}
+
+func init() {
+	f := serviceCmd.Flags()
+	f.StringVar(&keysSubject, "keys", "keys.*", "Wildcard NATS subject to receive keys of typists from")
+	f.StringVar(&resultsSubject, "results", "gokeybr.results", "NATS subject to publish progress and results to")
+	f.IntVar(&serviceIdle, "idle", int(app.DefaultIdleTimeout/time.Second),
+		"Session ends when typist sends no keys for that many seconds",
+	)
+	f.IntVarP(&serviceLength, "length", "l", 100, "Length of text in characters")
+	f.StringVar(&serviceWordsFile, "words-file", "/usr/share/dict/words",
+		"Words to type until there are enough stats for random text",
+	)
+	rootCmd.AddCommand(serviceCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/spf13/cobra"
+)
+
+var textSubject string
+
+var streamCmd = &cobra.Command{
+	Use:   "stream [flags]",
+	Short: "train on text published to NATS subject by some coordinator",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := app.NewNATSTextSource(natsURL, textSubject)
+		fatal(err)
+		defer source.Close()
+
+		a, err := newApp("")
+		fatal(err)
+		stream(a, false, source)
+
+		err = a.Run()
+		fatal(err)
+
+		saveStats(a, false)
+		if n := source.Dropped(); n > 0 {
+			fmt.Printf("%d chunks of text were dropped (marked with %q), because too many were waiting\n", n, app.TextGap)
+		}
+	},
+}
+
+func init() {
+	streamCmd.Flags().StringVar(&textSubject, "text-subject", "gokeybr.text",
+		"NATS subject to receive text to type from",
+	)
+	rootCmd.AddCommand(streamCmd)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"github.com/bunyk/gokeybr/app"
 	"github.com/bunyk/gokeybr/phrase"
 	"github.com/spf13/cobra"
 )
@@ -16,11 +15,8 @@ var textCmd = &cobra.Command{
 		text, skipped, err := phrase.FromFile(args[0], offset, limit)
 		fatal(err)
 
-		a, err := app.New(text)
+		a, err := newApp(text)
 		fatal(err)
-		a.Zen = zen
-		a.Mute = mute
-		a.MinSpeed = minSpeed
 		a.Offset = skipped
 
 		a.Run()
@@ -9,6 +9,7 @@ import (
 )
 
 var weakestLength int
+var weakestCover int
 
 var weakestCmd = &cobra.Command{
 	Use:   "weakest [flags]",
@@ -18,13 +19,16 @@ var weakestCmd = &cobra.Command{
 		if weakestLength < stats.MinSessionLength {
 			fmt.Printf("Sequence should be at least %d characters long\n", stats.MinSessionLength)
 		}
-		text, err := stats.WeakestTraining(weakestLength)
+		rng := newRand()
+		text, err := stats.WeakestTraining(testLength(weakestLength), weakestCover, rng)
 		fatal(err)
-		a, err := app.New(text)
+		a, err := newApp(text)
 		fatal(err)
-		a.Zen = zen
-		a.Mute = mute
-		a.MinSpeed = minSpeed
+		if endless { // stats are updated while typing, so next text trains what became weakest
+			stream(a, true, app.TextSourceFunc(func() (string, error) {
+				return stats.WeakestTraining(weakestLength, weakestCover, rng)
+			}))
+		}
 
 		err = a.Run()
 		fatal(err)
@@ -37,5 +41,9 @@ func init() {
 	weakestCmd.Flags().IntVarP(&weakestLength, "length", "l", 50,
 		"Minimal lenght in characters of generated text (default 50)",
 	)
+	weakestCmd.Flags().IntVarP(&weakestCover, "number", "n", stats.NCover,
+		"Number of weakest character combinations to train in one sequence",
+	)
+	addSeedFlag(weakestCmd)
 	rootCmd.AddCommand(weakestCmd)
 }
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bunyk/gokeybr/web"
+	"github.com/spf13/cobra"
+)
+
+var webAddr, webKeys, webResults string
+
+var webCmd = &cobra.Command{
+	Use:   "web [flags]",
+	Short: "serve typing page for browser, sending its keys to NATS",
+	Long: `Serves typing page for browser. Keys typed in it are published to --keys
+subject with name of typist appended, like keys of pub program. Progress of
+session published by "gokeybr service" to --results subject is shown on page.
+
+Try it locally:
+
+    gokeybr web --embedded
+    gokeybr service             (in other terminal)
+
+and open http://127.0.0.1:8080 in browser.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := web.NewBridge(natsURL, webKeys, webResults)
+		fatal(err)
+		defer b.Close()
+		fmt.Printf("Open http://%s in browser\n", webAddr)
+		fatal(http.ListenAndServe(webAddr, b.Handler()))
+	},
+}
+
+func init() {
+	f := webCmd.Flags()
+	f.StringVar(&webAddr, "addr", "127.0.0.1:8080", "Address to serve typing page on")
+	f.StringVar(&webKeys, "keys", "keys", "NATS subject to publish keys to, name of typist is appended")
+	f.StringVar(&webResults, "results", "gokeybr.results",
+		"NATS subject to receive progress from, name of typist is appended",
+	)
+	rootCmd.AddCommand(webCmd)
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/bunyk/gokeybr/app"
 	"github.com/bunyk/gokeybr/phrase"
+	"github.com/bunyk/gokeybr/stats"
 	"github.com/spf13/cobra"
 )
 
 var wordsCount int
+var wordsWeakest bool
 
 var wordsCmd = &cobra.Command{
 	Use:   "words [flags] [optional file to load words from (one word per line, \"-\" - stdin)]",
@@ -23,17 +25,30 @@ var wordsCmd = &cobra.Command{
 		if len(args) > 0 {
 			filename = args[0]
 		}
-		text, err := phrase.Words(filename, wordsCount)
+		rng := newRand()
+		generate := func(n int) (string, error) {
+			if wordsWeakest {
+				targets, err := stats.WeakestTrigrams(stats.NTargets)
+				if err != nil {
+					return "", err
+				}
+				return phrase.TargetedWords(filename, targets, n, rng)
+			}
+			return phrase.Words(filename, n, rng)
+		}
+		text, err := generate(testWords(wordsCount))
 		fatal(err)
-		a, err := app.New(text)
+		a, err := newApp(text)
 		fatal(err)
-		a.Zen = zen
-		a.Mute = mute
-		a.MinSpeed = minSpeed
+		if endless {
+			stream(a, wordsWeakest, app.TextSourceFunc(func() (string, error) {
+				return generate(wordsCount)
+			}))
+		}
 
 		err = a.Run()
 		fatal(err)
-		saveStats(a, false)
+		saveStats(a, wordsWeakest)
 	},
 }
 
@@ -41,5 +56,9 @@ func init() {
 	wordsCmd.Flags().IntVarP(&wordsCount, "number", "n", 10,
 		"Number of words to type (default 10)",
 	)
+	wordsCmd.Flags().BoolVarP(&wordsWeakest, "weakest", "w", false,
+		"Choose words containing your weakest character combinations",
+	)
+	addSeedFlag(wordsCmd)
 	rootCmd.AddCommand(wordsCmd)
 }
@@ -0,0 +1,228 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const FileAccess = 0644
+
+// DefaultProfile is a name of profile which files are stored directly in ~/.gokeybr
+const DefaultProfile = "default"
+
+// Profile is a name of profile which files are used. Files of other profiles
+// are stored in ~/.gokeybr/profiles/<name>/
+var Profile = DefaultProfile
+
+func rootDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".gokeybr")
+}
+
+// RootPath returns path to file or directory in ~/.gokeybr, shared by all profiles
+func RootPath(name string) string {
+	return filepath.Join(rootDir(), name)
+}
+
+// ProfileDir returns directory where files of given profile are stored
+func ProfileDir(profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return rootDir()
+	}
+	return filepath.Join(rootDir(), "profiles", profile)
+}
+
+// UseProfile switches to files of other profile, and returns function that switches back
+func UseProfile(profile string) (restore func()) {
+	prev := Profile
+	Profile = profile
+	return func() {
+		Profile = prev
+	}
+}
+
+// Profiles lists names of existing profiles
+func Profiles() ([]string, error) {
+	res := []string{DefaultProfile}
+	entries, err := ioutil.ReadDir(filepath.Join(rootDir(), "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			res = append(res, e.Name())
+		}
+	}
+	return res, nil
+}
+
+// ProfileExists returns true if directory of profile exists
+func ProfileExists(profile string) bool {
+	_, err := os.Stat(ProfileDir(profile))
+	return err == nil
+}
+
+// CreateProfile makes directory for files of new profile
+func CreateProfile(profile string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+	if ProfileExists(profile) {
+		return fmt.Errorf("profile %q already exists", profile)
+	}
+	return os.MkdirAll(ProfileDir(profile), os.ModePerm)
+}
+
+// DeleteProfile removes all files of profile
+func DeleteProfile(profile string) error {
+	if profile == DefaultProfile {
+		return fmt.Errorf("default profile could not be deleted")
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+	if !ProfileExists(profile) {
+		return fmt.Errorf("profile %q does not exist", profile)
+	}
+	return os.RemoveAll(ProfileDir(profile))
+}
+
+// ValidateProfileName checks that profile name could be used as directory name.
+// Wildcards of NATS subjects are rejected, so profile is not named after them.
+func ValidateProfileName(profile string) error {
+	if profile == "" || profile == "." || profile == ".." || strings.ContainsAny(profile, `/\*>`) {
+		return fmt.Errorf("invalid profile name %q", profile)
+	}
+	return nil
+}
+
+// Files are files of one profile. They are used without switching Profile, so
+// files of many profiles could be used at the same time.
+type Files struct {
+	profile string
+}
+
+// ProfileFiles returns files of given profile
+func ProfileFiles(profile string) Files {
+	return Files{profile: profile}
+}
+
+// current returns files of current profile
+func current() Files {
+	return ProfileFiles(Profile)
+}
+
+func (f Files) path(name string) string {
+	return filepath.Join(ProfileDir(f.profile), name)
+}
+
+func (f Files) mkdir() {
+	dir := ProfileDir(f.profile)
+	if _, err := os.Stat(dir); err != nil {
+		_ = os.MkdirAll(dir, os.ModePerm)
+	}
+}
+
+func SaveJSON(filename string, o interface{}) error {
+	return current().SaveJSON(filename, o)
+}
+
+func (f Files) SaveJSON(filename string, o interface{}) error {
+	data, err := json.MarshalIndent(o, "", " ")
+	if err != nil {
+		return err
+	}
+	f.mkdir()
+	return ioutil.WriteFile(f.path(filename), data, FileAccess)
+}
+
+func LoadJSON(filename string, v interface{}) error {
+	return current().LoadJSON(filename, v)
+}
+
+func (f Files) LoadJSON(filename string, v interface{}) error {
+	f.mkdir()
+	data, err := ioutil.ReadFile(f.path(filename))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func AppendJSONLine(filename string, v interface{}) error {
+	return current().AppendJSONLine(filename, v)
+}
+
+func (f Files) AppendJSONLine(filename string, v interface{}) error {
+	f.mkdir()
+	file, err := os.OpenFile(f.path(filename), os.O_APPEND|os.O_CREATE|os.O_WRONLY, FileAccess)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(file, string(data))
+	return err
+}
+
+// SaveJSONLines replaces content of file with values, JSON of each on separate line
+func SaveJSONLines(filename string, values []interface{}) error {
+	return current().SaveJSONLines(filename, values)
+}
+
+func (f Files) SaveJSONLines(filename string, values []interface{}) error {
+	var buf bytes.Buffer
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	f.mkdir()
+	return ioutil.WriteFile(f.path(filename), buf.Bytes(), FileAccess)
+}
+
+type JSONLinesIterator struct {
+	scanner *bufio.Scanner
+	file    *os.File
+}
+
+func NewJSONLinesIterator(filename string) (*JSONLinesIterator, error) {
+	return current().NewJSONLinesIterator(filename)
+}
+
+func (f Files) NewJSONLinesIterator(filename string) (*JSONLinesIterator, error) {
+	file, err := os.Open(f.path(filename))
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesIterator{
+		file:    file,
+		scanner: bufio.NewScanner(file),
+	}, nil
+}
+
+func (i JSONLinesIterator) Close() {
+	i.file.Close()
+}
+
+func (i JSONLinesIterator) UnmarshalNextLine(v interface{}) (bool, error) {
+	if !i.scanner.Scan() {
+		return false, i.scanner.Err()
+	}
+
+	return true, json.Unmarshal(i.scanner.Bytes(), v)
+}
@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	profiles, err := Profiles()
+	if err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfile}) {
+		t.Fatalf("Only default profile should exist, got %v, %v", profiles, err)
+	}
+	if err := CreateProfile("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateProfile("alice"); err == nil {
+		t.Errorf("Profile should not be created twice")
+	}
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`, "*", ">", "keys.*"} {
+		if err := CreateProfile(name); err == nil {
+			t.Errorf("Profile %q should not be created", name)
+		}
+	}
+	if err := CreateProfile("bob"); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = Profiles()
+	if err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfile, "alice", "bob"}) {
+		t.Errorf("Got profiles %v, %v", profiles, err)
+	}
+
+	restore := UseProfile("alice")
+	if err := SaveJSON("goals.json", []int{1}); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if _, err := os.Stat(filepath.Join(ProfileDir("alice"), "goals.json")); err != nil {
+		t.Errorf("File of profile should be saved in its directory: %v", err)
+	}
+	if Profile != DefaultProfile {
+		t.Errorf("Profile should be restored, got %q", Profile)
+	}
+
+	if err := DeleteProfile(DefaultProfile); err == nil {
+		t.Errorf("Default profile should not be deleted")
+	}
+	if err := DeleteProfile("carol"); err == nil {
+		t.Errorf("Deleting profile that does not exist should fail")
+	}
+	if err := DeleteProfile("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if ProfileExists("alice") {
+		t.Errorf("Deleted profile should not exist")
+	}
+	profiles, err = Profiles()
+	if err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfile, "bob"}) {
+		t.Errorf("Got profiles %v, %v", profiles, err)
+	}
+}
+
+func TestSaveJSONLines(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := AppendJSONLine("log.jsonl", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveJSONLines("log.jsonl", []interface{}{2, "three"}); err != nil {
+		t.Fatal(err)
+	}
+	iter, err := NewJSONLinesIterator("log.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	var got []interface{}
+	for {
+		var v interface{}
+		cont, err := iter.UnmarshalNextLine(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cont {
+			break
+		}
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, []interface{}{2.0, "three"}) {
+		t.Errorf("File should be replaced with new lines, got %v", got)
+	}
+}
@@ -4,7 +4,7 @@ go 1.13
 
 require (
 	github.com/gdamore/tcell/v2 v2.0.0-dev
-	github.com/nats-io/nats-server/v2 v2.9.15 // indirect
+	github.com/nats-io/nats-server/v2 v2.9.15
 	github.com/nats-io/nats.go v1.24.0
 	github.com/spf13/cobra v1.0.0
 	google.golang.org/protobuf v1.30.0 // indirect
@@ -10,7 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 	"unicode/utf8"
 
 	"github.com/bunyk/gokeybr/fs"
@@ -25,15 +24,15 @@ func FromFile(filename string, offset, minLength int) (string, int, error) {
 	return strings.Join(items, "\n"), skipped, nil
 }
 
-func Words(filename string, n int) (string, error) {
+// Words returns n random words from file, using rng as source of randomness
+func Words(filename string, n int, rng *rand.Rand) (string, error) {
 	words, _, err := readFileLines(filename, 0)
 	if err != nil {
 		return "", err
 	}
-	rand.Seed(time.Now().UTC().UnixNano())
 	var phrase []string
 	for i := 0; i < n; i++ {
-		w := words[rand.Intn(len(words))]
+		w := words[rng.Intn(len(words))]
 		phrase = append(phrase, w)
 	}
 	return strings.Join(phrase, " "), nil
@@ -137,3 +136,31 @@ func lastFileOffset(filename string) int {
 	}
 	return progressTable[filename]
 }
+
+// MergeProgress adds progress in files of other profile to the current one,
+// keeping greater of two offsets
+func MergeProgress(from string) error {
+	var other map[string]int
+	restore := fs.UseProfile(from)
+	err := fs.LoadJSON(ProgressFile, &other)
+	restore()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var progressTable map[string]int
+	if err := fs.LoadJSON(ProgressFile, &progressTable); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		progressTable = make(map[string]int)
+	}
+	for f, line := range other {
+		if line > progressTable[f] {
+			progressTable[f] = line
+		}
+	}
+	return fs.SaveJSON(ProgressFile, progressTable)
+}
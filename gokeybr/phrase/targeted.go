@@ -0,0 +1,145 @@
+package phrase
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/bunyk/gokeybr/stats"
+)
+
+// Number of words in generated sentence
+const minSentence = 4
+const maxSentence = 9
+
+type weightedWord struct {
+	word   string
+	weight float64
+}
+
+// wordPattern is a part of target trigram that could be found in a single word
+type wordPattern struct {
+	part string
+	// trigram started (or ended) with space, so part is beginning (or end) of word
+	prefix, suffix bool
+	score          float64
+}
+
+// newWordPattern converts target trigram to pattern to match words with. Trigrams
+// with space in the middle span two words, and could not be found in one word,
+// false is returned for them (and for trigrams with other whitespace).
+func newWordPattern(t stats.TrigramScore) (wordPattern, bool) {
+	r := []rune(strings.ToLower(t.Trigram))
+	p := wordPattern{score: t.Score}
+	if len(r) > 0 && r[0] == ' ' {
+		p.prefix = true
+		r = r[1:]
+	}
+	if len(r) > 0 && r[len(r)-1] == ' ' {
+		p.suffix = true
+		r = r[:len(r)-1]
+	}
+	for _, c := range r {
+		if unicode.IsSpace(c) {
+			return p, false
+		}
+	}
+	p.part = string(r)
+	return p, p.part != ""
+}
+
+// matches returns true when lowercased word contains pattern
+func (p wordPattern) matches(word string) bool {
+	switch {
+	case p.prefix && p.suffix:
+		return word == p.part
+	case p.prefix:
+		return strings.HasPrefix(word, p.part)
+	case p.suffix:
+		return strings.HasSuffix(word, p.part)
+	}
+	return strings.Contains(word, p.part)
+}
+
+// weighWords returns words that contain at least one of target trigrams,
+// each weighted by sum of scores of target trigrams it contains. Trigrams on
+// word boundary are matched with beginning or end of word.
+func weighWords(words []string, targets []stats.TrigramScore) []weightedWord {
+	patterns := make([]wordPattern, 0, len(targets))
+	for _, t := range targets {
+		if p, ok := newWordPattern(t); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	res := make([]weightedWord, 0)
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		lower := strings.ToLower(w)
+		weight := 0.0
+		for _, p := range patterns {
+			if p.matches(lower) {
+				weight += p.score
+			}
+		}
+		if weight > 0 {
+			res = append(res, weightedWord{w, weight})
+		}
+	}
+	return res
+}
+
+func pickWord(words []weightedWord, total float64, rng *rand.Rand) string {
+	choice := rng.Float64() * total
+	for _, w := range words {
+		choice -= w.weight
+		if choice <= 0 {
+			return w.word
+		}
+	}
+	return words[len(words)-1].word
+}
+
+// TargetedWords generates n words long text from words in file that contain
+// target trigrams, more often picking words with trigrams that have greater score.
+// Words are grouped in sentences, to look like a natural text. rng is a source of randomness.
+func TargetedWords(filename string, targets []stats.TrigramScore, n int, rng *rand.Rand) (string, error) {
+	lines, _, err := readFileLines(filename, 0)
+	if err != nil {
+		return "", err
+	}
+	words := weighWords(lines, targets)
+	if len(words) == 0 {
+		return "", fmt.Errorf("no words in %s contain any of your weakest character combinations", filename)
+	}
+	total := 0.0
+	for _, w := range words {
+		total += w.weight
+	}
+
+	sentences := make([]string, 0)
+	sentence := make([]string, 0, maxSentence)
+	sentenceLength := minSentence + rng.Intn(maxSentence-minSentence+1)
+	for i := 0; i < n; i++ {
+		w := pickWord(words, total, rng)
+		for try := 0; try < 10 && len(sentence) > 0 && w == sentence[len(sentence)-1]; try++ {
+			w = pickWord(words, total, rng) // do not repeat same word twice in a row
+		}
+		sentence = append(sentence, w)
+		if len(sentence) == sentenceLength || i == n-1 {
+			sentences = append(sentences, capitalize(strings.Join(sentence, " "))+".")
+			sentence = sentence[:0]
+			sentenceLength = minSentence + rng.Intn(maxSentence-minSentence+1)
+		}
+	}
+	return strings.Join(sentences, " "), nil
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
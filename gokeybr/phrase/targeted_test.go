@@ -0,0 +1,72 @@
+package phrase
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bunyk/gokeybr/stats"
+)
+
+var dictionary = []string{"the", "then", "other", "bathe", "Thomas", "a", "cat"}
+
+func TestWeighWords(t *testing.T) {
+	cases := []struct {
+		trigram string
+		want    string
+	}{
+		{"the", "bathe other the then"},
+		{"The", "bathe other the then"}, // words are matched ignoring case
+		{" th", "the then thomas"},      // beginning of word
+		{"he ", "bathe the"},            // end of word
+		{" a ", "a"},                    // whole word
+		{"e t", ""},                     // spans two words
+		{"a\nc", ""},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, w := range weighWords(dictionary, []stats.TrigramScore{{Trigram: c.trigram, Score: 1}}) {
+			got = append(got, strings.ToLower(w.word))
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != c.want {
+			t.Errorf("%q matched %v, want %q", c.trigram, got, c.want)
+		}
+	}
+
+	words := weighWords(dictionary, []stats.TrigramScore{
+		{Trigram: "the", Score: 2}, {Trigram: " th", Score: 1}, {Trigram: "e t", Score: 5},
+	})
+	weights := make(map[string]float64)
+	for _, w := range words {
+		weights[w.word] = w.weight
+	}
+	if weights["then"] != 3 || weights["other"] != 2 || weights["Thomas"] != 1 {
+		t.Errorf("Words should be weighted by scores of trigrams they contain, got %v", weights)
+	}
+}
+
+func TestTargetedWords(t *testing.T) {
+	f, err := ioutil.TempFile("", "words")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(strings.Join(dictionary, "\n") + "\n")
+	f.Close()
+
+	rng := rand.New(rand.NewSource(1))
+	text, err := TargetedWords(f.Name(), []stats.TrigramScore{{Trigram: " ca", Score: 1}}, 5, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Cat cat cat cat cat." {
+		t.Errorf("Expected only word starting with \"ca\", got %q", text)
+	}
+	if _, err := TargetedWords(f.Name(), []stats.TrigramScore{{Trigram: "e t", Score: 1}}, 5, rng); err == nil {
+		t.Errorf("Trigram spanning two words should not match any word")
+	}
+}
@@ -0,0 +1,259 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+const GoalsFile = "goals.json"
+
+// Kinds of goals
+const (
+	GoalWPM      = "wpm"      // reach speed in one session
+	GoalMinutes  = "minutes"  // train given number of minutes per day
+	GoalAccuracy = "accuracy" // percent of keys hit correctly in one session
+)
+
+func GoalKinds() []string {
+	return []string{GoalWPM, GoalMinutes, GoalAccuracy}
+}
+
+// Goal is a target that typist wants to reach
+type Goal struct {
+	Kind   string  `json:"kind"`
+	Target float64 `json:"target"`
+	// If not empty, only sessions of this mode (command, like "random") count
+	Mode     string     `json:"mode,omitempty"`
+	Created  time.Time  `json:"created"`
+	Achieved *time.Time `json:"achieved,omitempty"`
+}
+
+func (g Goal) String() string {
+	var res string
+	switch g.Kind {
+	case GoalWPM:
+		res = fmt.Sprintf("reach %.0f wpm", g.Target)
+	case GoalMinutes:
+		res = fmt.Sprintf("train %.0f minutes per day", g.Target)
+	case GoalAccuracy:
+		res = fmt.Sprintf("accuracy of %.1f%%", g.Target)
+	}
+	if g.Mode != "" {
+		res += " in " + g.Mode + " mode"
+	}
+	return res
+}
+
+func LoadGoals() ([]Goal, error) {
+	var goals []Goal
+	if err := fs.LoadJSON(GoalsFile, &goals); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return goals, nil
+}
+
+func AddGoal(kind string, target float64, mode string) error {
+	valid := false
+	for _, k := range GoalKinds() {
+		valid = valid || k == kind
+	}
+	if !valid {
+		return fmt.Errorf("unknown goal %q, available: %s", kind, strings.Join(GoalKinds(), ", "))
+	}
+	if target <= 0 {
+		return fmt.Errorf("goal target should be positive")
+	}
+	goals, err := LoadGoals()
+	if err != nil {
+		return err
+	}
+	goals = append(goals, Goal{Kind: kind, Target: target, Mode: mode, Created: time.Now()})
+	return fs.SaveJSON(GoalsFile, goals)
+}
+
+// RemoveGoal removes goal by its number in list (starting from 1)
+func RemoveGoal(n int) error {
+	goals, err := LoadGoals()
+	if err != nil {
+		return err
+	}
+	if n < 1 || n > len(goals) {
+		return fmt.Errorf("there is no goal #%d", n)
+	}
+	goals = append(goals[:n-1], goals[n:]...)
+	return fs.SaveJSON(GoalsFile, goals)
+}
+
+// sessionResult holds speed and accuracy of logged session
+type sessionResult struct {
+	Start    time.Time
+	Mode     string
+	WPM      float64
+	Accuracy float64 // percents
+	Active   float64 // seconds
+}
+
+func resultOf(e statLogEntry) sessionResult {
+	r := sessionResult{Start: e.startTime(), Mode: e.Mode, Accuracy: 100}
+	if len(e.Timeline) > 0 {
+		r.Active = e.Timeline[len(e.Timeline)-1] - e.Idle
+	}
+	if r.Active > 0 {
+		r.WPM = calcWPM(len(e.Timeline), r.Active)
+	}
+	errors := 0
+	for _, n := range e.Errors {
+		errors += n
+	}
+	if len(e.Timeline)+errors > 0 {
+		r.Accuracy = float64(len(e.Timeline)) / float64(len(e.Timeline)+errors) * 100
+	}
+	return r
+}
+
+func loadResults() ([]sessionResult, error) {
+	iter, err := fs.NewJSONLinesIterator(LogStatsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer iter.Close()
+	var res []sessionResult
+	for {
+		var e statLogEntry
+		cont, err := iter.UnmarshalNextLine(&e)
+		if err != nil {
+			return nil, err
+		}
+		if !cont {
+			return res, nil
+		}
+		res = append(res, resultOf(e))
+	}
+}
+
+func dayOf(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// minutesByDay sums active time of sessions for each day, in minutes
+func minutesByDay(results []sessionResult) map[string]float64 {
+	minutes := make(map[string]float64)
+	for _, r := range results {
+		minutes[dayOf(r.Start)] += r.Active / 60.0
+	}
+	return minutes
+}
+
+// streak counts days in a row, ending today (or yesterday, if there was
+// no training today yet), for which done returns true
+func streak(now time.Time, done func(day string) bool) int {
+	n := 0
+	d := now
+	if !done(dayOf(d)) {
+		d = d.AddDate(0, 0, -1)
+	}
+	for done(dayOf(d)) {
+		n++
+		d = d.AddDate(0, 0, -1)
+	}
+	return n
+}
+
+// goalProgress describes progress of goal, and returns true if it is reached now
+func goalProgress(g Goal, results []sessionResult, minutes map[string]float64, now time.Time) (string, bool) {
+	var last *sessionResult
+	best := 0.0
+	for i := range results {
+		r := results[i]
+		if g.Mode != "" && r.Mode != g.Mode {
+			continue
+		}
+		last = &results[i]
+		if g.Kind == GoalWPM && r.WPM > best {
+			best = r.WPM
+		}
+		if g.Kind == GoalAccuracy && r.Accuracy > best {
+			best = r.Accuracy
+		}
+	}
+	switch g.Kind {
+	case GoalMinutes:
+		today := minutes[dayOf(now)]
+		days := streak(now, func(day string) bool { return minutes[day] >= g.Target })
+		return fmt.Sprintf(
+			"%.1f of %.0f minutes today, %d day(s) streak", today, g.Target, days,
+		), today >= g.Target
+	case GoalWPM:
+		if last == nil {
+			return "no sessions yet", false
+		}
+		return fmt.Sprintf(
+			"%.1f wpm in last session, best %.1f wpm", last.WPM, best,
+		), last.WPM >= g.Target
+	case GoalAccuracy:
+		if last == nil {
+			return "no sessions yet", false
+		}
+		return fmt.Sprintf(
+			"%.1f%% in last session, best %.1f%%", last.Accuracy, best,
+		), last.Accuracy >= g.Target
+	}
+	return "", false
+}
+
+// GoalsReport evaluates goals against sessions log, marks newly achieved ones,
+// and returns report of progress
+func GoalsReport() (string, error) {
+	goals, err := LoadGoals()
+	if err != nil {
+		return "", err
+	}
+	results, err := loadResults()
+	if err != nil {
+		return "", err
+	}
+	minutes := minutesByDay(results)
+	now := time.Now()
+
+	res := make([]string, 0)
+	print := func(f string, args ...interface{}) {
+		res = append(res, fmt.Sprintf(f, args...))
+	}
+	days := streak(now, func(day string) bool { return minutes[day] > 0 })
+	print("Training streak: %d day(s)\n", days)
+	if len(goals) == 0 {
+		print("No goals set, add one with \"gokeybr goals add\"\n")
+		return strings.Join(res, ""), nil
+	}
+	changed := false
+	print("Goals:\n")
+	for i, g := range goals {
+		progress, reached := goalProgress(g, results, minutes, now)
+		mark := " "
+		if reached || g.Achieved != nil {
+			mark = "x"
+		}
+		print("%2d. [%s] %s: %s\n", i+1, mark, g, progress)
+		if reached && g.Achieved == nil {
+			goals[i].Achieved = &now
+			changed = true
+			print("    Goal achieved! Congratulations!\n")
+		}
+	}
+	if changed {
+		if err := fs.SaveJSON(GoalsFile, goals); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(res, ""), nil
+}
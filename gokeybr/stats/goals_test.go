@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// at returns noon of given day of 2021, in local time zone like days of streaks
+func at(month time.Month, day int) time.Time {
+	return time.Date(2021, month, day, 12, 0, 0, 0, time.Local)
+}
+
+func TestStreak(t *testing.T) {
+	cases := []struct {
+		name    string
+		now     time.Time
+		trained []time.Time
+		want    int
+	}{
+		{"nothing", at(3, 10), nil, 0},
+		{"today only", at(3, 10), []time.Time{at(3, 10)}, 1},
+		{"three days", at(3, 10), []time.Time{at(3, 8), at(3, 9), at(3, 10)}, 3},
+		{"not yet today", at(3, 10), []time.Time{at(3, 8), at(3, 9)}, 2},
+		{"missed yesterday", at(3, 10), []time.Time{at(3, 8), at(3, 10)}, 1},
+		{"missed today and yesterday", at(3, 10), []time.Time{at(3, 7), at(3, 8)}, 0},
+		{"month boundary", at(3, 1), []time.Time{at(2, 27), at(2, 28), at(3, 1)}, 3},
+		{"year boundary", time.Date(2021, 1, 1, 0, 30, 0, 0, time.Local),
+			[]time.Time{time.Date(2020, 12, 31, 23, 50, 0, 0, time.Local)}, 1},
+	}
+	for _, c := range cases {
+		days := make(map[string]bool)
+		for _, d := range c.trained {
+			days[dayOf(d)] = true
+		}
+		if got := streak(c.now, func(day string) bool { return days[day] }); got != c.want {
+			t.Errorf("%s: got streak of %d days, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestResultOf(t *testing.T) {
+	r := resultOf(statLogEntry{
+		Start:    "2021-03-10T12:00:00Z",
+		Timeline: []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Idle:     4,
+		Errors:   []int{0, 1, 0, 0, 1, 0, 0, 0, 0, 0},
+		Mode:     "random",
+	})
+	// 10 characters in 5 seconds of active typing, 2 wrong keys
+	if r.Active != 5 || r.WPM != 24 || math.Abs(r.Accuracy-100.0*10/12) > 1e-9 || r.Mode != "random" {
+		t.Errorf("Got %+v", r)
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	now := at(3, 10)
+	session := func(start time.Time, mode string, wpm, accuracy, minutes float64) sessionResult {
+		return sessionResult{Start: start, Mode: mode, WPM: wpm, Accuracy: accuracy, Active: minutes * 60}
+	}
+	results := []sessionResult{
+		session(at(3, 8), "random", 60, 90, 12),
+		session(at(3, 9), "random", 45, 97, 10),
+		session(at(3, 10).Add(-time.Hour), "text", 30, 99, 3),
+		session(at(3, 10), "random", 52, 95, 4),
+	}
+	cases := []struct {
+		goal     Goal
+		results  []sessionResult
+		progress string
+		reached  bool
+	}{
+		{Goal{Kind: GoalWPM, Target: 50}, results, "52.0 wpm in last session, best 60.0 wpm", true},
+		{Goal{Kind: GoalWPM, Target: 55}, results, "52.0 wpm in last session, best 60.0 wpm", false},
+		{Goal{Kind: GoalWPM, Target: 40, Mode: "text"}, results, "30.0 wpm in last session, best 30.0 wpm", false},
+		{Goal{Kind: GoalWPM, Target: 40, Mode: "words"}, results, "no sessions yet", false},
+		{Goal{Kind: GoalWPM, Target: 40}, nil, "no sessions yet", false},
+		{Goal{Kind: GoalAccuracy, Target: 95}, results, "95.0% in last session, best 99.0%", true},
+		{Goal{Kind: GoalAccuracy, Target: 98, Mode: "random"}, results, "95.0% in last session, best 97.0%", false},
+		{Goal{Kind: GoalMinutes, Target: 10}, results, "7.0 of 10 minutes today, 2 day(s) streak", false},
+		{Goal{Kind: GoalMinutes, Target: 7}, results, "7.0 of 7 minutes today, 3 day(s) streak", true},
+		{Goal{Kind: GoalMinutes, Target: 12}, results, "7.0 of 12 minutes today, 0 day(s) streak", false},
+		{Goal{Kind: GoalMinutes, Target: 5}, nil, "0.0 of 5 minutes today, 0 day(s) streak", false},
+	}
+	for _, c := range cases {
+		progress, reached := goalProgress(c.goal, c.results, minutesByDay(c.results), now)
+		if progress != c.progress || reached != c.reached {
+			t.Errorf("%s: got %q, reached: %v; want %q, %v", c.goal, progress, reached, c.progress, c.reached)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"container/heap"
+	"math/rand"
+	"strings"
+)
+
+// trigramGraph treats each trigram abc as graph edge ab -> bc, with the
+// weight = 1 / score of trigram, so paths through trigrams that need
+// training most are the shortest.
+type trigramGraph struct {
+	adjacent map[string][]edge
+}
+
+type edge struct {
+	to string
+	w  float64
+}
+
+func newTrigramGraph(trigrams []TrigramScore) trigramGraph {
+	g := trigramGraph{adjacent: make(map[string][]edge)}
+	for _, trigram := range trigrams {
+		if trigram.Score > 0 {
+			h, t := headTail(trigram.Trigram)
+			g.adjacent[h] = append(g.adjacent[h], edge{to: t, w: 1.0 / trigram.Score})
+		}
+	}
+	return g
+}
+
+type queueItem struct {
+	vertex   string
+	distance float64
+}
+
+// vertexQueue is a min-heap of vertices by distance
+type vertexQueue []queueItem
+
+func (q vertexQueue) Len() int            { return len(q) }
+func (q vertexQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q vertexQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *vertexQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *vertexQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// dijkstra computes distances of shortest paths from start to every reachable
+// vertex, and map that says from which vertex goes shortest path to current.
+// Search stops when all vertices from stopAt are reached (if given).
+func (g trigramGraph) dijkstra(start string, stopAt map[string]bool) (map[string]float64, map[string]string) {
+	distance := map[string]float64{start: 0}
+	predecessor := make(map[string]string)
+	done := make(map[string]bool)
+	toReach := len(stopAt)
+	q := &vertexQueue{{start, 0}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(queueItem)
+		v := item.vertex
+		if done[v] {
+			continue
+		}
+		done[v] = true
+		if stopAt[v] {
+			toReach--
+			if toReach == 0 {
+				break
+			}
+		}
+		for _, e := range g.adjacent[v] {
+			d := item.distance + e.w
+			if old, ok := distance[e.to]; !ok || d < old {
+				distance[e.to] = d
+				predecessor[e.to] = v
+				heap.Push(q, queueItem{e.to, d})
+			}
+		}
+	}
+	return distance, predecessor
+}
+
+// path returns vertices of shortest path from start (excluding) to finish (including)
+func path(predecessor map[string]string, start, finish string) []string {
+	res := make([]string, 0)
+	for step := finish; step != start; step = predecessor[step] {
+		res = append(res, step)
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+// drill returns sequence of characters that could be repeated and contains
+// every target trigram. It is built as a closed walk that goes through each
+// target edge, getting from one target to the nearest next one by the shortest
+// path. When some target could not be reached, or walk could not be closed -
+// parts of it are separated by spaces.
+func (g trigramGraph) drill(targets []string) []rune {
+	remaining := make([]string, 0, len(targets))
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		if !seen[t] {
+			seen[t] = true
+			remaining = append(remaining, t)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	segments := make([][]string, 0)
+	h, t := headTail(remaining[0])
+	walk := []string{h, t}
+	remaining = remaining[1:]
+	for len(remaining) > 0 {
+		cur := walk[len(walk)-1]
+		heads := make(map[string]bool)
+		for _, r := range remaining {
+			h, _ := headTail(r)
+			heads[h] = true
+		}
+		distance, predecessor := g.dijkstra(cur, heads)
+		best := -1
+		for i, r := range remaining {
+			h, _ := headTail(r)
+			if d, ok := distance[h]; ok && (best < 0 || d < distance[headOf(remaining[best])]) {
+				best = i
+			}
+		}
+		if best < 0 { // nothing reachable, start new segment from the weakest remaining
+			segments = append(segments, walk)
+			h, t := headTail(remaining[0])
+			walk = []string{h, t}
+			remaining = remaining[1:]
+			continue
+		}
+		h, t := headTail(remaining[best])
+		walk = append(walk, path(predecessor, cur, h)...)
+		walk = append(walk, t)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	// try to close walk into a loop
+	if len(segments) == 0 {
+		start, cur := walk[0], walk[len(walk)-1]
+		_, predecessor := g.dijkstra(cur, map[string]bool{start: true})
+		if _, ok := predecessor[start]; ok || start == cur {
+			walk = append(walk, path(predecessor, cur, start)...)
+			loop := make([]rune, 0, len(walk))
+			for _, v := range walk[:len(walk)-1] {
+				loop = append(loop, []rune(v)[0])
+			}
+			return loop
+		}
+	}
+	segments = append(segments, walk)
+	parts := make([]string, len(segments))
+	for i, s := range segments {
+		part := []rune(s[0])
+		for _, v := range s[1:] {
+			part = append(part, []rune(v)[1])
+		}
+		parts[i] = string(part)
+	}
+	return []rune(strings.Join(parts, " ") + " ")
+}
+
+func headOf(trigram string) string {
+	h, _ := headTail(trigram)
+	return h
+}
+
+// NCover is a default number of weakest trigrams covered by one weakest drill
+const NCover = 1
+
+// weakestSequence generates text of given length, that repeats path through
+// cover weakest trigrams, starting from position in loop chosen by rng
+func weakestSequence(trigrams []TrigramScore, length, cover int, rng *rand.Rand) string {
+	if cover < 1 {
+		cover = 1
+	}
+	if cover > len(trigrams) {
+		cover = len(trigrams)
+	}
+	targets := make([]string, cover)
+	for i := range targets {
+		targets[i] = trigrams[i].Trigram
+	}
+	loop := newTrigramGraph(trigrams).drill(targets)
+	shift := rng.Intn(len(loop))
+	loop = append(loop[shift:], loop[:shift]...)
+	return wrap(loop, length)
+}
+
+// wrap repeats loop (slice of runes) enough times to get string of length n
+func wrap(loop []rune, l int) string {
+	buffer := make([]rune, l)
+	for i := range buffer {
+		buffer[i] = loop[i%len(loop)]
+	}
+	return string(buffer)
+}
+
+// split abc to ab & bc (with unicode support)
+func headTail(trigram string) (string, string) {
+	r := []rune(trigram)
+	return string(r[:2]), string(r[1:])
+}
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDrillCoversTargets(t *testing.T) {
+	trigrams := []TrigramScore{
+		{"abc", 5}, {"xyz", 4}, {"bcx", 1}, {"cxy", 1}, {"yza", 1}, {"zab", 1},
+	}
+	loop := string(newTrigramGraph(trigrams).drill([]string{"abc", "xyz"}))
+	text := loop + loop
+	for _, target := range []string{"abc", "xyz"} {
+		if !strings.Contains(text, target) {
+			t.Errorf("Drill %q should contain %q", loop, target)
+		}
+	}
+	if strings.Contains(loop, " ") {
+		t.Errorf("Targets are connected, so drill %q should not be split", loop)
+	}
+
+	loop = string(newTrigramGraph(trigrams[:2]).drill([]string{"abc", "xyz"}))
+	if loop != "abc xyz " {
+		t.Errorf("Unconnected targets should be separated by spaces, got %q", loop)
+	}
+}
+
+// syntheticTrigrams returns all trigrams of n characters alphabet, with random scores
+func syntheticTrigrams(n int) []TrigramScore {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []rune("abcdefghijklmnopqrstuvwxyz0123456789 .,;(){}[]")[:n]
+	res := make([]TrigramScore, 0, n*n*n)
+	for _, a := range alphabet {
+		for _, b := range alphabet {
+			for _, c := range alphabet {
+				res = append(res, TrigramScore{string([]rune{a, b, c}), rng.Float64()})
+			}
+		}
+	}
+	return res
+}
+
+func BenchmarkWeakestSequence(b *testing.B) {
+	trigrams := syntheticTrigrams(40) // 64000 trigrams
+	rng := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		weakestSequence(trigrams, 100, 1, rng)
+	}
+}
+
+func BenchmarkWeakestSequenceCover10(b *testing.B) {
+	trigrams := syntheticTrigrams(40)
+	rng := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		weakestSequence(trigrams, 100, 10, rng)
+	}
+}
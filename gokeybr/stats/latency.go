@@ -0,0 +1,196 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+// Names of latencies measured for remote keys
+const (
+	// from capture of key by publisher till it is received by trainer
+	LatencyCaptureReceive = "capture-receive"
+	// from publishing till receiving, that is time spent in network and NATS server
+	LatencyPublishReceive = "publish-receive"
+	// from receiving key till screen that shows it is rendered
+	LatencyReceiveRender = "receive-render"
+)
+
+// ClockOffset is an estimate of how much clock of publisher is ahead of
+// trainer one, with round trip delay of the exchange it was estimated from
+type ClockOffset struct {
+	Source  string  `json:"source"`
+	Offset  float64 `json:"offset"` // ms
+	Delay   float64 `json:"delay"`  // ms
+	Samples int     `json:"samples"`
+}
+
+var latencyNames = []string{LatencyCaptureReceive, LatencyPublishReceive, LatencyReceiveRender}
+
+const (
+	histogramMin  = 0.1 // ms, upper bound of the first bucket
+	histogramBase = 1.1 // each bucket is 10% wider than previous
+)
+
+// Histogram of latencies in milliseconds. Buckets grow exponentially, so
+// percentiles are precise up to 10%, histogram is small to store in session log,
+// and histograms of many sessions could be merged.
+type Histogram struct {
+	Count   int         `json:"n"`
+	Sum     float64     `json:"sum"`
+	Max     float64     `json:"max"`
+	Buckets map[int]int `json:"b"` // bucket index -> number of values in it
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{Buckets: make(map[int]int)}
+}
+
+func bucketOf(ms float64) int {
+	if ms <= histogramMin {
+		return 0
+	}
+	return int(math.Ceil(math.Log(ms/histogramMin) / math.Log(histogramBase)))
+}
+
+func bucketUpper(i int) float64 {
+	return histogramMin * math.Pow(histogramBase, float64(i))
+}
+
+// Add adds duration to histogram. Negative durations (because of clocks
+// of publisher and trainer being not in sync) are counted as zero.
+func (h *Histogram) Add(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	if ms < 0 {
+		ms = 0
+	}
+	h.Count++
+	h.Sum += ms
+	if ms > h.Max {
+		h.Max = ms
+	}
+	h.Buckets[bucketOf(ms)]++
+}
+
+// Merge adds values of other histogram
+func (h *Histogram) Merge(o *Histogram) {
+	h.Count += o.Count
+	h.Sum += o.Sum
+	if o.Max > h.Max {
+		h.Max = o.Max
+	}
+	for b, n := range o.Buckets {
+		h.Buckets[b] += n
+	}
+}
+
+func (h *Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// Percentile returns upper bound of bucket with p-th percentile (0..100) value, in ms
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	buckets := make([]int, 0, len(h.Buckets))
+	for b := range h.Buckets {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+	rank := int(math.Ceil(p / 100 * float64(h.Count)))
+	seen := 0
+	for _, b := range buckets {
+		seen += h.Buckets[b]
+		if seen >= rank {
+			return math.Min(bucketUpper(b), h.Max)
+		}
+	}
+	return h.Max
+}
+
+// Summary returns "p50/p95/p99" of histogram, in ms
+func (h *Histogram) Summary() string {
+	return fmt.Sprintf("%.1f/%.1f/%.1f", h.Percentile(50), h.Percentile(95), h.Percentile(99))
+}
+
+// LatencyReport shows latency percentiles of last n sessions with remote keys, and of all of them
+func LatencyReport(n int) (string, error) {
+	iter, err := fs.NewJSONLinesIterator(LogStatsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "No sessions yet", nil
+		}
+		return "", err
+	}
+	defer iter.Close()
+	type sessionLatency struct {
+		start   string
+		latency map[string]*Histogram
+	}
+	var sessions []sessionLatency
+	total := make(map[string]*Histogram)
+	for _, name := range latencyNames {
+		total[name] = NewHistogram()
+	}
+	for {
+		var e statLogEntry
+		cont, err := iter.UnmarshalNextLine(&e)
+		if err != nil {
+			return "", err
+		}
+		if !cont {
+			break
+		}
+		if len(e.Latency) == 0 {
+			continue
+		}
+		sessions = append(sessions, sessionLatency{e.Start, e.Latency})
+		for name, h := range e.Latency {
+			if total[name] != nil {
+				total[name].Merge(h)
+			}
+		}
+	}
+	if len(sessions) == 0 {
+		return "No sessions with remote keys yet", nil
+	}
+	if len(sessions) > n {
+		sessions = sessions[len(sessions)-n:]
+	}
+	res := []string{
+		"Latency of remote keys, p50/p95/p99 in milliseconds\n\n",
+		fmt.Sprintf("%-25s | %5s | %-20s | %-20s | %-20s\n", "Session", "Keys",
+			LatencyCaptureReceive, LatencyPublishReceive, LatencyReceiveRender),
+	}
+	row := func(name string, latency map[string]*Histogram) {
+		cols := make([]string, 0, len(latencyNames))
+		count := 0
+		for _, l := range latencyNames {
+			h := latency[l]
+			if h == nil || h.Count == 0 {
+				cols = append(cols, "-")
+				continue
+			}
+			cols = append(cols, h.Summary())
+			if h.Count > count {
+				count = h.Count
+			}
+		}
+		res = append(res, fmt.Sprintf("%-25s | %5d | %-20s | %-20s | %-20s\n",
+			name, count, cols[0], cols[1], cols[2]))
+	}
+	for _, s := range sessions {
+		row(s.start, s.latency)
+	}
+	row("All sessions", total)
+	return strings.Join(res, ""), nil
+}
@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+	h.Add(-time.Millisecond) // clock skew
+	for _, c := range []struct{ p, want float64 }{{50, 50}, {95, 95}, {99, 99}, {100, 100}} {
+		got := h.Percentile(c.p)
+		if math.Abs(got-c.want)/c.want > 0.1 {
+			t.Errorf("Percentile(%.0f) = %.2f, want %.0f within 10%%", c.p, got, c.want)
+		}
+	}
+	o := NewHistogram()
+	o.Add(time.Second)
+	h.Merge(o)
+	if h.Count != 102 || h.Max != 1000 {
+		t.Errorf("After merge got count %d and max %.0f", h.Count, h.Max)
+	}
+}
@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"unicode"
+)
+
+// MarkovChain generates text, where probability of each next character
+// depends on Order-1 previous characters (Order is size of n-gram:
+// 2 for bigrams, 3 for trigrams, 4 for 4-grams...).
+//
+// When there is no known continuation of some context (dead end), chain
+// backs off to shorter context, down to plain frequencies of characters.
+// Smoothing mixes shorter context probabilities into longer context ones,
+// so transitions never seen in n-grams get small probability too.
+type MarkovChain struct {
+	Order int
+	// Weight of shorter context distribution added to each context distribution,
+	// relative to total weight of context. 0 - no smoothing.
+	Smoothing float64
+	// Maximal length of generated word, 0 - unlimited
+	MaxWordLength int
+
+	// links[k] maps contexts of length k to weights of next characters
+	links []map[string]map[rune]float64
+}
+
+func NewMarkovChain(order int, smoothing float64) *MarkovChain {
+	if order < 1 {
+		order = 1
+	}
+	m := &MarkovChain{
+		Order:     order,
+		Smoothing: smoothing,
+		links:     make([]map[string]map[rune]float64, order),
+	}
+	for i := range m.links {
+		m.links[i] = make(map[string]map[rune]float64)
+	}
+	return m
+}
+
+// Add adds n-gram with given weight to chain. N-grams of length other than Order are ignored.
+func (m *MarkovChain) Add(ngram string, weight float64) {
+	r := []rune(ngram)
+	if len(r) != m.Order || weight <= 0 {
+		return
+	}
+	next := r[len(r)-1]
+	for k := 0; k < m.Order; k++ { // add to every shorter context for back off
+		ctx := string(r[len(r)-1-k : len(r)-1])
+		if m.links[k][ctx] == nil {
+			m.links[k][ctx] = make(map[rune]float64)
+		}
+		m.links[k][ctx][next] += weight
+	}
+}
+
+// Empty returns true if no n-grams were added
+func (m MarkovChain) Empty() bool {
+	return len(m.links[0]) == 0
+}
+
+// distribution returns probabilities of characters that could follow context,
+// backing off to shorter contexts if needed
+func (m MarkovChain) distribution(context []rune) map[rune]float64 {
+	if len(context) > m.Order-1 {
+		context = context[len(context)-m.Order+1:]
+	}
+	var res map[rune]float64
+	for k := 0; k <= len(context); k++ { // from shortest to longest context
+		links := m.links[k][string(context[len(context)-k:])]
+		if len(links) == 0 {
+			break // longer contexts will be unknown too
+		}
+		total := 0.0
+		for _, w := range links {
+			total += w
+		}
+		dist := make(map[rune]float64, len(links)+len(res))
+		for r, w := range links {
+			dist[r] = w / total
+		}
+		if res != nil && m.Smoothing > 0 {
+			for r, p := range res {
+				dist[r] = (dist[r] + m.Smoothing*p) / (1.0 + m.Smoothing)
+			}
+			for r := range links { // renormalize characters that are not in shorter context
+				if _, ok := res[r]; !ok {
+					dist[r] = dist[r] / (1.0 + m.Smoothing)
+				}
+			}
+		}
+		res = dist
+	}
+	return res
+}
+
+// Next chooses character to follow given text
+func (m MarkovChain) Next(text []rune, rng *rand.Rand) rune {
+	dist := m.distribution(text)
+
+	wordLength := 0
+	for i := len(text) - 1; i >= 0 && !unicode.IsSpace(text[i]); i-- {
+		wordLength++
+	}
+	afterSpace := len(text) == 0 || wordLength == 0
+	tooLong := m.MaxWordLength > 0 && wordLength >= m.MaxWordLength
+
+	// map iteration order is random, so sort candidates to be deterministic
+	candidates := make([]rune, 0, len(dist))
+	for r := range dist {
+		candidates = append(candidates, r)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+	// word boundaries: do not put two spaces in a row, and end too long words
+	filtered := candidates[:0:0]
+	for _, r := range candidates {
+		if afterSpace && unicode.IsSpace(r) {
+			continue
+		}
+		if tooLong && !unicode.IsSpace(r) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	if len(filtered) == 0 {
+		if tooLong {
+			return ' '
+		}
+		filtered = candidates
+	}
+
+	total := 0.0
+	for _, r := range filtered {
+		total += dist[r]
+	}
+	choice := rng.Float64() * total
+	for _, r := range filtered {
+		choice -= dist[r]
+		if choice <= 0 {
+			return r
+		}
+	}
+	return filtered[len(filtered)-1]
+}
+
+// Generate continues start text until it is at least length characters long,
+// and then until the end of current word, when MaxWordLength is set.
+func (m MarkovChain) Generate(start string, length int, rng *rand.Rand) string {
+	text := []rune(start)
+	if m.Empty() {
+		return start
+	}
+	for len(text) < length {
+		text = append(text, m.Next(text, rng))
+	}
+	if m.MaxWordLength > 0 {
+		for i := 0; i < m.MaxWordLength && !unicode.IsSpace(text[len(text)-1]); i++ {
+			r := m.Next(text, rng)
+			if unicode.IsSpace(r) {
+				break
+			}
+			text = append(text, r)
+		}
+	}
+	return string(text)
+}
+
+// Parameters of Markov chain used by markovSequence
+const DefaultMarkovOrder = 3
+const MarkovSmoothing = 0.05
+const MaxWordLength = 12
+
+// minScore is used instead of zero score, so trained trigrams still could appear
+const minScore = 0.00000001
+
+// trigramsChain builds Markov chain of given order from trigram scores.
+// Bigram chain sums scores of trigrams by their last two characters,
+// and 4-gram chain joins overlapping trigrams abc & bcd into abcd,
+// with geometric mean of their scores.
+func trigramsChain(trigrams []TrigramScore, order int) *MarkovChain {
+	if order < 2 {
+		order = 2
+	}
+	if order > 4 {
+		order = 4
+	}
+	m := NewMarkovChain(order, MarkovSmoothing)
+	m.MaxWordLength = MaxWordLength
+	scores := make(map[string]float64, len(trigrams))
+	byHead := make(map[string][]string)
+	for _, ts := range trigrams {
+		if len([]rune(ts.Trigram)) != 3 {
+			continue
+		}
+		sc := ts.Score
+		if sc <= 0 {
+			sc = minScore
+		}
+		scores[ts.Trigram] = sc
+		h, _ := headTail(ts.Trigram)
+		byHead[h] = append(byHead[h], ts.Trigram)
+	}
+	for _, ts := range trigrams {
+		sc, ok := scores[ts.Trigram]
+		if !ok {
+			continue
+		}
+		switch order {
+		case 2:
+			_, t := headTail(ts.Trigram)
+			m.Add(t, sc)
+		case 3:
+			m.Add(ts.Trigram, sc)
+		case 4:
+			_, t := headTail(ts.Trigram)
+			for _, next := range byHead[t] {
+				m.Add(ts.Trigram+string([]rune(next)[2]), math.Sqrt(sc*scores[next]))
+			}
+		}
+	}
+	return m
+}
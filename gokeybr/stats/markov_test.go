@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestMarkovDistribution(t *testing.T) {
+	m := NewMarkovChain(3, 0)
+	m.Add("abc", 3)
+	m.Add("abd", 1)
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[rune]int)
+	const n = 10000
+	for i := 0; i < n; i++ {
+		counts[m.Next([]rune("ab"), rng)]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("Without smoothing only seen transitions should be generated, got %v", counts)
+	}
+	if got := float64(counts['c']) / n; math.Abs(got-0.75) > 0.02 {
+		t.Errorf("Expected 'c' after \"ab\" with probability 0.75, got %f", got)
+	}
+}
+
+func TestMarkovSmoothing(t *testing.T) {
+	m := NewMarkovChain(3, 0.5)
+	m.Add("abc", 1)
+	m.Add("xbd", 1)
+	dist := m.distribution([]rune("ab"))
+	if dist['d'] <= 0 {
+		t.Errorf("Smoothing should give probability to transition not seen after \"ab\", got %v", dist)
+	}
+	if dist['c'] <= dist['d'] {
+		t.Errorf("Seen transition should be more probable than smoothed, got %v", dist)
+	}
+	total := 0.0
+	for _, p := range dist {
+		total += p
+	}
+	if math.Abs(total-1.0) > 1e-9 {
+		t.Errorf("Probabilities should sum up to 1, got %f", total)
+	}
+}
+
+func TestMarkovDeadEnd(t *testing.T) {
+	m := NewMarkovChain(4, 0)
+	m.Add("abcd", 1)
+	// "bcd" is never continued, so chain should back off to shorter contexts
+	a := m.Generate("abc", 30, rand.New(rand.NewSource(5)))
+	b := m.Generate("abc", 30, rand.New(rand.NewSource(5)))
+	if len(a) != 30 {
+		t.Errorf("Expected 30 characters, got %q", a)
+	}
+	if a != b {
+		t.Errorf("Dead end recovery should be deterministic for same seed, got %q and %q", a, b)
+	}
+}
+
+func TestMarkovWordBoundaries(t *testing.T) {
+	m := NewMarkovChain(2, 0)
+	m.MaxWordLength = 5
+	m.Add("aa", 10)
+	m.Add("a ", 1)
+	m.Add(" a", 1)
+	m.Add("  ", 10)
+	text := m.Generate("a", 200, rand.New(rand.NewSource(3)))
+	if strings.Contains(text, "  ") {
+		t.Errorf("Generated text should not contain double spaces: %q", text)
+	}
+	for _, w := range strings.Fields(text) {
+		if len(w) > 5 {
+			t.Errorf("Word %q is longer than 5 characters", w)
+		}
+	}
+}
+
+func TestTrigramsChainOrders(t *testing.T) {
+	trigrams := []TrigramScore{{"abc", 2}, {"bcd", 1}, {"cda", 0}}
+	for order := 2; order <= 4; order++ {
+		m := trigramsChain(trigrams, order)
+		if m.Empty() {
+			t.Errorf("Chain of order %d should not be empty", order)
+		}
+	}
+	if dist := trigramsChain(trigrams, 4).distribution([]rune("abc")); dist['d'] < 0.99 {
+		t.Errorf("4-gram abcd should be built from abc and bcd, got %v", dist)
+	}
+}
@@ -0,0 +1,78 @@
+package stats
+
+import "sort"
+
+// Gaps between keystrokes longer than DefaultPauseFactor medians of the session
+// are considered to be pauses (reading, fixing a typo, coffee...)
+const DefaultPauseFactor = 8.0
+
+// MinPause is a gap in seconds that is never considered to be a pause,
+// even if typing is very fast and median gap is small
+const MinPause = 1.0
+
+// pauseCutoff returns gap between keystrokes (in seconds) after which typist
+// is considered to be idle, and median gap. When threshold is given - it is
+// used, otherwise cutoff is computed relative to median gap of session.
+func pauseCutoff(timeline []float64, threshold, factor float64) (cutoff, median float64) {
+	gaps := make([]float64, 0, len(timeline))
+	for i := 1; i < len(timeline); i++ {
+		gaps = append(gaps, timeline[i]-timeline[i-1])
+	}
+	if len(gaps) > 0 {
+		sort.Float64s(gaps)
+		median = gaps[len(gaps)/2]
+	}
+	if threshold > 0 {
+		return threshold, median
+	}
+	if factor <= 0 {
+		factor = DefaultPauseFactor
+	}
+	cutoff = median * factor
+	if cutoff < MinPause {
+		cutoff = MinPause
+	}
+	return cutoff, median
+}
+
+// activeTimeline returns copy of timeline, where each pause is replaced by
+// median gap between keystrokes, list of positions in text that were typed
+// after pause, and total idle time removed from timeline.
+func activeTimeline(timeline []float64, threshold, factor float64) (active []float64, paused []bool, idle float64) {
+	active = make([]float64, len(timeline))
+	paused = make([]bool, len(timeline))
+	if len(timeline) == 0 {
+		return
+	}
+	cutoff, median := pauseCutoff(timeline, threshold, factor)
+	active[0] = timeline[0]
+	for i := 1; i < len(timeline); i++ {
+		gap := timeline[i] - timeline[i-1]
+		if gap > cutoff {
+			paused[i] = true
+			idle += gap - median
+			gap = median
+		}
+		active[i] = active[i-1] + gap
+	}
+	return
+}
+
+// IdleTime splits duration of session, using pause settings of current profile
+func IdleTime(timeline []float64) (active, idle float64) {
+	return CurrentStore().IdleTime(timeline)
+}
+
+// IdleTime splits duration of session with given timeline to time actively
+// spent typing and time of pauses, using pause settings from stats file
+func (s Store) IdleTime(timeline []float64) (active, idle float64) {
+	if len(timeline) == 0 {
+		return 0, 0
+	}
+	var threshold, factor float64
+	if st, err := s.load(); err == nil {
+		threshold, factor = st.PauseThreshold, st.PauseFactor
+	}
+	activeTL, _, idle := activeTimeline(timeline, threshold, factor)
+	return activeTL[len(activeTL)-1], idle
+}
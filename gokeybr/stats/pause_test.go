@@ -0,0 +1,22 @@
+package stats
+
+import "testing"
+
+func TestActiveTimeline(t *testing.T) {
+	timeline := []float64{0, 0.2, 0.4, 0.6, 10.6, 10.8, 11.0}
+	active, paused, idle := activeTimeline(timeline, 0, 0)
+	if idle != 9.8 {
+		t.Errorf("Expected 9.8 seconds of idle time, got %f", idle)
+	}
+	if !paused[4] || paused[3] || paused[5] {
+		t.Errorf("Only 5th character should be typed after pause, got %v", paused)
+	}
+	if got := active[len(active)-1]; got < 1.19 || got > 1.21 {
+		t.Errorf("Expected 1.2 seconds of active typing, got %f", got)
+	}
+
+	_, _, idle = activeTimeline(timeline, 20, 0)
+	if idle != 0 {
+		t.Errorf("Gaps shorter than threshold should not be pauses, got %f idle", idle)
+	}
+}
@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+// merge adds stats of other typist to s
+func (s *stats) merge(other stats) {
+	s.TotalCharsTyped += other.TotalCharsTyped
+	s.TotalSessionsDuration += other.TotalSessionsDuration
+	s.TotalIdleDuration += other.TotalIdleDuration
+	s.SessionsCount += other.SessionsCount
+	for t, o := range other.Trigrams {
+		tr := s.Trigrams[t]
+		tr.Count += o.Count
+		tr.Errors += o.Errors
+		if tr.Duration.Version == 0 {
+			tr.Duration = NewStat(s.WindowCapacity, s.HalfLife)
+		}
+		for _, v := range o.Duration.Values {
+			tr.Duration.Append(float64(v) / MillisecondsInSecond)
+		}
+		s.Trigrams[t] = tr
+	}
+}
+
+// readProfile loads stats, sessions log, goals and schedule of other profile
+func readProfile(profile string) (*stats, []statLogEntry, []Goal, schedule, error) {
+	restore := fs.UseProfile(profile)
+	defer restore()
+
+	st := &stats{Trigrams: make(map[string]trigramStat)}
+	if err := fs.LoadJSON(StatsFile, st); err != nil && !os.IsNotExist(err) {
+		return nil, nil, nil, nil, err
+	}
+	st.migrate()
+
+	log, err := readLog()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	goals, err := LoadGoals()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	sch, err := loadSchedule()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return st, log, goals, sch, nil
+}
+
+// readLog reads sessions log of current profile
+func readLog() ([]statLogEntry, error) {
+	iter, err := fs.NewJSONLinesIterator(LogStatsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer iter.Close()
+	var log []statLogEntry
+	for {
+		var e statLogEntry
+		cont, err := iter.UnmarshalNextLine(&e)
+		if err != nil {
+			return nil, err
+		}
+		if !cont {
+			return log, nil
+		}
+		log = append(log, e)
+	}
+}
+
+// mergeLogs returns sessions of both logs, in order of their start
+func mergeLogs(log, other []statLogEntry) []statLogEntry {
+	res := append(append([]statLogEntry(nil), log...), other...)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].startTime().Before(res[j].startTime()) })
+	return res
+}
+
+// mergeGoals adds to goals the other ones that are not set yet. Goal set in
+// both profiles is achieved when it was achieved first.
+func mergeGoals(goals, other []Goal) []Goal {
+	for _, o := range other {
+		i := 0
+		for i < len(goals) && (goals[i].Kind != o.Kind || goals[i].Target != o.Target || goals[i].Mode != o.Mode) {
+			i++
+		}
+		if i == len(goals) {
+			goals = append(goals, o)
+			continue
+		}
+		if o.Achieved != nil && (goals[i].Achieved == nil || o.Achieved.Before(*goals[i].Achieved)) {
+			goals[i].Achieved = o.Achieved
+		}
+	}
+	return goals
+}
+
+// MergeProfile adds stats, sessions log, goals and review schedule
+// of other profile to the current one
+func MergeProfile(from string) error {
+	if from == fs.Profile {
+		return fmt.Errorf("could not merge profile %q into itself", from)
+	}
+	if !fs.ProfileExists(from) {
+		return fmt.Errorf("profile %q does not exist", from)
+	}
+	otherStats, otherLog, otherGoals, otherSchedule, err := readProfile(from)
+	if err != nil {
+		return err
+	}
+
+	st, err := loadStats()
+	if err != nil {
+		return err
+	}
+	st.merge(*otherStats)
+	if err := fs.SaveJSON(StatsFile, st); err != nil {
+		return err
+	}
+
+	log, err := readLog()
+	if err != nil {
+		return err
+	}
+	lines := make([]interface{}, 0, len(log)+len(otherLog))
+	for _, e := range mergeLogs(log, otherLog) {
+		lines = append(lines, e)
+	}
+	if err := fs.SaveJSONLines(LogStatsFile, lines); err != nil {
+		return err
+	}
+
+	goals, err := LoadGoals()
+	if err != nil {
+		return err
+	}
+	if len(otherGoals) > 0 {
+		if err := fs.SaveJSON(GoalsFile, mergeGoals(goals, otherGoals)); err != nil {
+			return err
+		}
+	}
+
+	sch, err := loadSchedule()
+	if err != nil {
+		return err
+	}
+	for t, r := range otherSchedule {
+		if mine, ok := sch[t]; !ok || r.Due.Before(mine.Due) {
+			sch[t] = r // review earlier of two
+		}
+	}
+	if len(otherSchedule) > 0 {
+		return fs.SaveJSON(ScheduleFile, sch)
+	}
+	return nil
+}
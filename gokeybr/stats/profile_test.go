@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+func testSession(start time.Time, text string) Session {
+	timeline := make([]float64, len(text))
+	for i := range timeline {
+		timeline[i] = float64(i) * 0.2
+	}
+	return Session{Start: start, Text: []rune(text), Timeline: timeline}
+}
+
+func TestMergeProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	statsCache = make(map[string]*stats)
+	day := func(d int) time.Time { return time.Date(2021, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	if err := fs.CreateProfile("bob"); err != nil {
+		t.Fatal(err)
+	}
+	restore := fs.UseProfile("bob")
+	for _, s := range []Session{testSession(day(1), "bob one"), testSession(day(3), "bob three")} {
+		if err := SaveSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddGoal(GoalWPM, 50, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddGoal(GoalMinutes, 10, ""); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+
+	if err := SaveSession(testSession(day(2), "alice two")); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddGoal(GoalWPM, 50, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MergeProfile(fs.DefaultProfile); err == nil {
+		t.Errorf("Profile should not be merged into itself")
+	}
+	if err := MergeProfile("carol"); err == nil {
+		t.Errorf("Profile that does not exist should not be merged")
+	}
+	if err := MergeProfile("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := readLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, e := range log {
+		texts = append(texts, e.Text)
+	}
+	if len(texts) != 3 || texts[0] != "bob one" || texts[1] != "alice two" || texts[2] != "bob three" {
+		t.Errorf("Sessions should be merged in order of start, got %q", texts)
+	}
+	goals, err := LoadGoals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goals) != 2 || goals[0].Kind != GoalWPM || goals[1].Kind != GoalMinutes {
+		t.Errorf("Same goal should be added once, got %v", goals)
+	}
+	st, err := loadStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.SessionsCount != 3 || st.TotalCharsTyped != len("bob one")+len("alice two")+len("bob three") {
+		t.Errorf("Stats should be summed, got %d sessions and %d characters", st.SessionsCount, st.TotalCharsTyped)
+	}
+}
+
+func TestMergeGoals(t *testing.T) {
+	early := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	goals := mergeGoals(
+		[]Goal{{Kind: GoalWPM, Target: 50, Achieved: &late}, {Kind: GoalWPM, Target: 60}},
+		[]Goal{{Kind: GoalWPM, Target: 50, Achieved: &early}, {Kind: GoalWPM, Target: 60, Mode: "text"}},
+	)
+	if len(goals) != 3 || goals[0].Achieved != &early || goals[1].Achieved != nil || goals[2].Mode != "text" {
+		t.Errorf("Got %+v", goals)
+	}
+}
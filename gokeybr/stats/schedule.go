@@ -0,0 +1,222 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+const ScheduleFile = "schedule.json"
+
+// How many of the weakest trigrams are considered for daily training
+const DailyCandidates = 50
+
+// Ease factor of newly scheduled trigram, and minimal ease factor, as in SM-2
+const InitialEase = 2.5
+const MinEase = 1.3
+
+const day = 24 * time.Hour
+
+// review keeps state of spaced repetition for one trigram
+type review struct {
+	LastDrilled time.Time `json:"last"`
+	Due         time.Time `json:"due"`
+	Ease        float64   `json:"ease"`
+	Interval    float64   `json:"interval"` // days
+	Repetitions int       `json:"reps"`
+}
+
+type schedule map[string]review
+
+func loadSchedule() (schedule, error) {
+	sch := make(schedule)
+	if err := fs.LoadJSON(ScheduleFile, &sch); err != nil {
+		if os.IsNotExist(err) {
+			return make(schedule), nil
+		}
+		return nil, err
+	}
+	return sch, nil
+}
+
+// due returns trigrams from candidates that should be drilled at given time,
+// most overdue first. Trigrams that were never drilled are due.
+func (sch schedule) due(candidates []TrigramScore, now time.Time) []string {
+	type item struct {
+		trigram string
+		overdue time.Duration
+		score   float64
+	}
+	items := make([]item, 0)
+	for _, c := range candidates {
+		r, ok := sch[c.Trigram]
+		if ok && r.Due.After(now) {
+			continue
+		}
+		overdue := time.Duration(0)
+		if ok {
+			overdue = now.Sub(r.Due)
+		}
+		items = append(items, item{c.Trigram, overdue, c.Score})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].overdue != items[j].overdue {
+			return items[i].overdue > items[j].overdue
+		}
+		return items[i].score > items[j].score
+	})
+	res := make([]string, len(items))
+	for i, it := range items {
+		res[i] = it.trigram
+	}
+	return res
+}
+
+// update applies SM-2 algorithm to review of trigram, given quality
+// of recall from 0 (complete blackout) to 5 (perfect)
+func (sch schedule) update(trigram string, quality int, now time.Time) {
+	r, ok := sch[trigram]
+	if !ok {
+		r.Ease = InitialEase
+	}
+	if quality < 3 {
+		r.Repetitions = 0
+		r.Interval = 1
+	} else {
+		r.Repetitions++
+		switch r.Repetitions {
+		case 1:
+			r.Interval = 1
+		case 2:
+			r.Interval = 6
+		default:
+			r.Interval = math.Round(r.Interval * r.Ease)
+		}
+	}
+	q := float64(5 - quality)
+	r.Ease += 0.1 - q*(0.08+q*0.02)
+	if r.Ease < MinEase {
+		r.Ease = MinEase
+	}
+	r.LastDrilled = now
+	r.Due = now.Add(time.Duration(r.Interval * float64(day)))
+	sch[trigram] = r
+}
+
+// improvementQuality converts change of average trigram duration to SM-2 quality
+func improvementQuality(before, after float64) int {
+	if after <= 0 || before <= 0 {
+		return 0
+	}
+	ratio := before / after // greater than 1 when typed faster than before
+	switch {
+	case ratio >= 1.10:
+		return 5
+	case ratio >= 1.02:
+		return 4
+	case ratio >= 0.98:
+		return 3
+	case ratio >= 0.90:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// DrillItem is a trigram drilled in daily session, with its average duration before session
+type DrillItem struct {
+	Trigram string
+	Before  float64
+}
+
+// Drill is a daily training session composed of trigrams due for review
+type Drill struct {
+	Text  string
+	Items []DrillItem
+}
+
+// DailyTraining composes training session of given length from at most n
+// weak trigrams that are due for review
+func DailyTraining(length, n int) (*Drill, error) {
+	if length == 0 {
+		length = 100
+	}
+	if n < 1 {
+		n = 1
+	}
+	trigrams, err := getTrigrams()
+	if err != nil {
+		return nil, err
+	}
+	st, err := loadStats()
+	if err != nil {
+		return nil, err
+	}
+	sch, err := loadSchedule()
+	if err != nil {
+		return nil, err
+	}
+	candidates := trigrams
+	if len(candidates) > DailyCandidates {
+		candidates = candidates[:DailyCandidates]
+	}
+	due := sch.due(candidates, time.Now())
+	if len(due) == 0 {
+		return nil, fmt.Errorf("Nothing is due for review today, come back tomorrow")
+	}
+	if len(due) > n {
+		due = due[:n]
+	}
+
+	graph := newTrigramGraph(trigrams)
+	drill := &Drill{}
+	parts := make([]string, len(due))
+	partLength := length / len(due)
+	if partLength < MinSessionLength {
+		partLength = MinSessionLength
+	}
+	for i, t := range due {
+		drill.Items = append(drill.Items, DrillItem{
+			Trigram: t,
+			Before:  st.Trigrams[t].Duration.Average(st.AverageCharDuration() * 3),
+		})
+		parts[i] = wrap(graph.drill([]string{t}), partLength)
+	}
+	drill.Text = strings.Join(parts, " ")
+	return drill, nil
+}
+
+// Review updates schedule of drilled trigrams that were typed, comparing their
+// average duration before session and after stats were updated with it.
+func (d Drill) Review(typed []rune) (string, error) {
+	st, err := loadStats()
+	if err != nil {
+		return "", err
+	}
+	sch, err := loadSchedule()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	res := make([]string, 0)
+	for _, it := range d.Items {
+		if !strings.Contains(string(typed), it.Trigram) {
+			continue // not reached in this session
+		}
+		after := st.Trigrams[it.Trigram].Duration.Average(0)
+		sch.update(it.Trigram, improvementQuality(it.Before, after), now)
+		res = append(res, fmt.Sprintf(
+			"%#v: %4.2fs -> %4.2fs, next review in %.0f day(s)",
+			it.Trigram, it.Before, after, sch[it.Trigram].Interval,
+		))
+	}
+	if len(res) == 0 {
+		return "No drilled trigrams were typed, schedule is not changed", nil
+	}
+	return strings.Join(res, "\n"), fs.SaveJSON(ScheduleFile, sch)
+}
@@ -0,0 +1,29 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleUpdate(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sch := make(schedule)
+	candidates := []TrigramScore{{"abc", 2}, {"bcd", 1}}
+	if due := sch.due(candidates, now); len(due) != 2 || due[0] != "abc" {
+		t.Fatalf("New trigrams should be due, weakest first, got %v", due)
+	}
+
+	sch.update("abc", 5, now)
+	sch.update("abc", 5, now)
+	if r := sch["abc"]; r.Interval != 6 || r.Ease <= InitialEase {
+		t.Errorf("Two good reviews should give 6 days interval and greater ease, got %+v", r)
+	}
+	if due := sch.due(candidates, now.Add(day)); len(due) != 1 || due[0] != "bcd" {
+		t.Errorf("Only not reviewed trigram should be due, got %v", due)
+	}
+
+	sch.update("abc", 1, now)
+	if r := sch["abc"]; r.Interval != 1 || r.Repetitions != 0 {
+		t.Errorf("Failed review should reset interval, got %+v", r)
+	}
+}
@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ScoringModel decides how important it is to train trigram.
+// Greater score means trigram will be trained more.
+type ScoringModel interface {
+	// Name is used to select model with --scoring flag
+	Name() string
+	// Params describes parameters of model, for report
+	Params() string
+	Score(ts TrigramStat, ctx ScoringContext) float64
+}
+
+// TrigramStat is what scoring models know about trigram
+type TrigramStat struct {
+	// Number of times trigram was typed outside of training sessions
+	Count int
+	// Exponentially weighted average duration of typing trigram in seconds,
+	// ScoringContext.DefaultDuration when it was not typed yet
+	Average float64
+	// Plain average of last durations, 0 when trigram was not typed yet
+	Mean float64
+	// Number of durations measured
+	Samples int
+	// Wrong keys hit while typing second or third character of trigram
+	Errors int
+}
+
+// ErrorRate returns number of errors per trigram typed
+func (ts TrigramStat) ErrorRate() float64 {
+	if ts.Samples == 0 {
+		return 0
+	}
+	return float64(ts.Errors) / float64(ts.Samples)
+}
+
+// scoringStat converts stored trigram stats to values scoring models use
+func (ts trigramStat) scoringStat(ctx ScoringContext) TrigramStat {
+	return TrigramStat{
+		Count:   ts.Count,
+		Average: ts.Duration.Average(ctx.DefaultDuration),
+		Mean:    ts.Duration.Mean(),
+		Samples: ts.Duration.Count,
+		Errors:  ts.Errors,
+	}
+}
+
+// ScoringContext holds values computed from all stats, that are needed for scoring
+type ScoringContext struct {
+	// Duration used for trigrams that were not typed yet
+	DefaultDuration float64
+	// Median of average durations of all trigrams
+	MedianDuration float64
+}
+
+func (s stats) scoringContext() ScoringContext {
+	durations := make([]float64, 0, len(s.Trigrams))
+	for _, ts := range s.Trigrams {
+		if ts.Duration.Count > 0 {
+			durations = append(durations, ts.Duration.Average(0))
+		}
+	}
+	ctx := ScoringContext{DefaultDuration: s.AverageCharDuration() * 3}
+	if len(durations) > 0 {
+		sort.Float64s(durations)
+		ctx.MedianDuration = durations[len(durations)/2]
+	}
+	return ctx
+}
+
+// EffortScoring approximates time that will be spent typing this trigram:
+// it is total frequency of trigram (it's count) multiplied by how far is
+// current average speed of typing it from SpeedOfLight.
+type EffortScoring struct {
+	SpeedOfLight float64 // wpm
+}
+
+func (m EffortScoring) Name() string { return "effort" }
+func (m EffortScoring) Params() string {
+	return fmt.Sprintf("speed of light %.0f wpm", m.SpeedOfLight)
+}
+func (m EffortScoring) Score(ts TrigramStat, ctx ScoringContext) float64 {
+	duration := ts.Average
+	return float64(ts.Count) * effortResult(duration, m.SpeedOfLight)
+}
+
+// FrequencyScoring is like EffortScoring, but uses logarithm of frequency,
+// so rare but slow trigrams are not overshadowed by very frequent ones.
+type FrequencyScoring struct {
+	SpeedOfLight float64 // wpm
+}
+
+func (m FrequencyScoring) Name() string { return "frequency" }
+func (m FrequencyScoring) Params() string {
+	return fmt.Sprintf("speed of light %.0f wpm, log-scaled frequency", m.SpeedOfLight)
+}
+func (m FrequencyScoring) Score(ts TrigramStat, ctx ScoringContext) float64 {
+	duration := ts.Average
+	return math.Log1p(float64(ts.Count)) * effortResult(duration, m.SpeedOfLight)
+}
+
+// ErrorScoring is EffortScoring increased proportionally to rate of errors
+// made while typing trigram.
+type ErrorScoring struct {
+	SpeedOfLight float64 // wpm
+	// How much score grows when there is one error per trigram typed
+	ErrorWeight float64
+}
+
+func (m ErrorScoring) Name() string { return "errors" }
+func (m ErrorScoring) Params() string {
+	return fmt.Sprintf("speed of light %.0f wpm, error weight %.1f", m.SpeedOfLight, m.ErrorWeight)
+}
+func (m ErrorScoring) Score(ts TrigramStat, ctx ScoringContext) float64 {
+	effort := EffortScoring{m.SpeedOfLight}.Score(ts, ctx)
+	return effort * (1.0 + m.ErrorWeight*ts.ErrorRate())
+}
+
+// RelativeScoring compares average duration of trigram to median one,
+// so only trigrams typed slower than your usual speed are trained.
+type RelativeScoring struct{}
+
+func (m RelativeScoring) Name() string   { return "relative" }
+func (m RelativeScoring) Params() string { return "relative to median trigram time" }
+func (m RelativeScoring) Score(ts TrigramStat, ctx ScoringContext) float64 {
+	if ctx.MedianDuration <= 0 {
+		return 0
+	}
+	duration := ts.Average
+	slowness := duration/ctx.MedianDuration - 1.0
+	if slowness < 0 {
+		return 0
+	}
+	return float64(ts.Count) * slowness
+}
+
+// DefaultErrorWeight is ErrorWeight of "errors" scoring model
+const DefaultErrorWeight = 2.0
+
+// Scoring is a model used to choose trigrams to train
+var Scoring ScoringModel = EffortScoring{SpeedOfLight: speedOfLight}
+
+// ScoringModels lists names of available scoring models
+func ScoringModels() []string {
+	return []string{"effort", "frequency", "errors", "relative"}
+}
+
+// SetScoring selects scoring model by name. speed is a speed of light
+// in wpm for models that use it, 0 for default.
+func SetScoring(name string, speed float64) error {
+	if speed <= 0 {
+		speed = speedOfLight
+	}
+	switch name {
+	case "effort", "":
+		Scoring = EffortScoring{SpeedOfLight: speed}
+	case "frequency":
+		Scoring = FrequencyScoring{SpeedOfLight: speed}
+	case "errors":
+		Scoring = ErrorScoring{SpeedOfLight: speed, ErrorWeight: DefaultErrorWeight}
+	case "relative":
+		Scoring = RelativeScoring{}
+	default:
+		return fmt.Errorf(
+			"unknown scoring model %q, available: %s",
+			name, strings.Join(ScoringModels(), ", "),
+		)
+	}
+	return nil
+}
+
+func describeScoring(m ScoringModel) string {
+	return fmt.Sprintf("%s, %s", m.Name(), m.Params())
+}
@@ -0,0 +1,38 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/bunyk/gokeybr/stats"
+)
+
+// slowestScoring is model defined outside of stats package
+type slowestScoring struct{}
+
+func (slowestScoring) Name() string   { return "slowest" }
+func (slowestScoring) Params() string { return "" }
+func (slowestScoring) Score(ts stats.TrigramStat, ctx stats.ScoringContext) float64 {
+	return ts.Average
+}
+
+var _ stats.ScoringModel = slowestScoring{}
+
+func TestScoringModels(t *testing.T) {
+	ctx := stats.ScoringContext{DefaultDuration: 1}
+	ts := stats.TrigramStat{Count: 10, Average: 0.5, Samples: 10}
+	effort := stats.EffortScoring{SpeedOfLight: 150}.Score(ts, ctx)
+	if effort <= 0 {
+		t.Errorf("Effort score = %f, want positive", effort)
+	}
+	ts.Errors = 5
+	if ts.ErrorRate() != 0.5 {
+		t.Errorf("ErrorRate() = %f, want 0.5", ts.ErrorRate())
+	}
+	withErrors := stats.ErrorScoring{SpeedOfLight: 150, ErrorWeight: 2}.Score(ts, ctx)
+	if withErrors != effort*2 {
+		t.Errorf("Error score = %f, want %f", withErrors, effort*2)
+	}
+	if s := (slowestScoring{}).Score(ts, ctx); s != 0.5 {
+		t.Errorf("Custom score = %f", s)
+	}
+}
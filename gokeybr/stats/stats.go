@@ -0,0 +1,588 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+// TODO: maybe use integer values in miliseconds, to save space?
+// Even microseconds will save 4 chars per datapoint
+
+const MinSessionLength = 5
+
+const LogStatsFile = "sessions_log.jsonl"
+const StatsFile = "stats.json"
+
+// Session is a result of one typing session
+type Session struct {
+	Start    time.Time
+	Text     []rune
+	Timeline []float64
+	// Number of wrong keys hit before typing each character of text
+	Errors []int
+	// Characters that were typed wrong and left uncorrected
+	Mistyped []bool
+	// Source (local keyboard, or remote typist) that typed each character
+	Typists []string
+	// Training sessions do not count trigram frequencies
+	Training bool
+	// Seed used to generate text of session, or 0 if it was not generated
+	Seed int64
+	// Command used to start session, like "text" or "random"
+	Mode string
+	// Intervals when session was paused, they are not included in timeline
+	Pauses []PauseInterval
+	// Name of test mode, like "time:1m0s", or empty when session is not a test
+	Test string
+	// Duration of test in seconds, used to compute its result
+	Duration float64
+	// Latencies of remote keys, see LatencyCaptureReceive and others
+	Latency map[string]*Histogram
+	// Estimated offsets of clocks of publishers, used to correct latencies
+	Clocks []ClockOffset
+}
+
+// PauseInterval is a time when session was paused. Start is a point of
+// session timeline (which does not include pauses), and End - Start is how
+// long pause lasted, in seconds.
+type PauseInterval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// SaveSession appends session to log of current profile and updates its stats
+func SaveSession(session Session) error {
+	return CurrentStore().SaveSession(session)
+}
+
+// SaveSession appends session to log and updates stats with it. Sessions
+// shorter than MinSessionLength are not saved, except results of tests.
+func (s Store) SaveSession(session Session) error {
+	text, timeline := session.Text, session.Timeline
+	if len(text) != len(timeline) {
+		return fmt.Errorf(
+			"Length of text (%d) does not match leght of timeline (%d)! Stats not saved.",
+			len(text), len(timeline),
+		)
+	}
+	// result of test is logged even when it ended too soon to update stats
+	short := len(text) < MinSessionLength
+	if short && session.Test == "" {
+		fmt.Printf("Not updating stats for session only %d characters long\n", len(text))
+		return nil
+	}
+	_, idle := s.IdleTime(timeline)
+	var result *TestResult
+	if session.Test != "" {
+		r := NewTestResult(session, session.Duration)
+		result = &r
+	}
+	if err := s.files.AppendJSONLine(
+		LogStatsFile,
+		statLogEntry{
+			Start:    session.Start.Format(time.RFC3339),
+			Text:     string(text),
+			Timeline: timeline,
+			Errors:   session.Errors,
+			Mistyped: mistypedPositions(session.Mistyped),
+			Typists:  typistRuns(session.Typists),
+			Idle:     idle,
+			Seed:     session.Seed,
+			Mode:     session.Mode,
+			Pauses:   session.Pauses,
+			Result:   result,
+			Latency:  session.Latency,
+			Clocks:   session.Clocks,
+		},
+	); err != nil {
+		return err
+	}
+	if short {
+		fmt.Printf("Not updating stats for session only %d characters long\n", len(text))
+		return nil
+	}
+	return s.update(session)
+}
+
+// RandomTraining generates text for current profile, see Store.RandomTraining
+func RandomTraining(length, order int, rng *rand.Rand) (string, error) {
+	return CurrentStore().RandomTraining(length, order, rng)
+}
+
+// RandomTraining generates text of given length from Markov chain of given order,
+// using rng as source of randomness, so same seed gives same text for same stats
+func (s Store) RandomTraining(length, order int, rng *rand.Rand) (string, error) {
+	trigrams, err := s.trigrams()
+	if err != nil {
+		return "", err
+	}
+	if length == 0 {
+		length = 100
+	}
+	return markovSequence(trigrams, length, order, rng), nil
+}
+
+func getTrigrams() ([]TrigramScore, error) {
+	return CurrentStore().trigrams()
+}
+
+// trigrams returns trigrams to train, or error when there are too few of them
+func (s Store) trigrams() ([]TrigramScore, error) {
+	stats, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	trigrams := stats.trigramsToTrain()
+	if len(trigrams) < NWeakest {
+		return nil, fmt.Errorf("Not enought stats yet to generate good exercise")
+	}
+	return trigrams, err
+}
+
+// WeakestTrigrams returns at most n trigrams that need to be trained most, with their scores
+func WeakestTrigrams(n int) ([]TrigramScore, error) {
+	trigrams, err := getTrigrams()
+	if err != nil {
+		return nil, err
+	}
+	if len(trigrams) > n {
+		trigrams = trigrams[:n]
+	}
+	return trigrams, nil
+}
+
+// WeakestTraining generates text of given length repeating path through
+// cover weakest trigrams, starting from position in loop chosen by rng
+func WeakestTraining(length, cover int, rng *rand.Rand) (string, error) {
+	if length == 0 {
+		length = 100
+	}
+	trigrams, err := getTrigrams()
+	if err != nil {
+		return "", err
+	}
+	return weakestSequence(trigrams, length, cover, rng), nil
+}
+
+// Typing speed we think is unreachable
+const speedOfLight = 150.0 // wpm
+
+func effortResult(trigramTime, unreachable float64) float64 {
+	speed := time2wpm(trigramTime)
+	q := speed / unreachable
+	q = q * q
+	if q > 1.0 {
+		return 0
+	}
+	return math.Sqrt(1.0 - q)
+}
+
+// TypistRun is a part of session text, starting at position From,
+// typed by one typist. Next run starts where this one ends.
+type TypistRun struct {
+	From   int    `json:"from"`
+	Typist string `json:"typist"`
+}
+
+// typistRuns compresses typists of each character to runs, or returns nil
+// when everything was typed on local keyboard
+func typistRuns(typists []string) []TypistRun {
+	var runs []TypistRun
+	local := true
+	for i, t := range typists {
+		if t != "" && t != "local" {
+			local = false
+		}
+		if len(runs) == 0 || runs[len(runs)-1].Typist != t {
+			runs = append(runs, TypistRun{From: i, Typist: t})
+		}
+	}
+	if local {
+		return nil
+	}
+	return runs
+}
+
+func mistypedPositions(mistyped []bool) []int {
+	var res []int
+	for i, m := range mistyped {
+		if m {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func (s Store) update(session Session) error {
+	stats, err := s.load()
+	if err != nil {
+		return err
+	}
+	stats.addSession(session)
+	return s.files.SaveJSON(StatsFile, stats)
+}
+
+type stats struct {
+	// Version of trigram statistics format, see StatVersion
+	Version               int
+	TotalCharsTyped       int
+	TotalSessionsDuration float64
+	SessionsCount         int
+	// Number of last samples kept for each trigram, and half-life (in samples)
+	// of average trigram duration. Zero means default.
+	WindowCapacity int
+	HalfLife       float64
+	// Gap between keystrokes in seconds after which typist is considered idle.
+	// When zero, it is PauseFactor (DefaultPauseFactor when zero) median gaps of session.
+	PauseThreshold float64
+	PauseFactor    float64
+	// Time of pauses, not included in TotalSessionsDuration
+	TotalIdleDuration float64
+	Trigrams          map[string]trigramStat
+}
+
+func (s stats) AverageCharDuration() float64 {
+	return s.TotalSessionsDuration / float64(s.TotalCharsTyped)
+}
+
+type trigramStat struct {
+	Count    int     `json:"c"`
+	Legacy   *Window `json:"d,omitempty"` // stats before StatVersion 1, migrated on load
+	Duration Stat    `json:"s"`
+	// Number of wrong keys hit while typing second or third character of trigram
+	Errors int `json:"e,omitempty"`
+}
+
+type TrigramScore struct {
+	Trigram string
+	Score   float64
+}
+
+// return list of trigrams with their relative importance to train
+// the more frequent is trigram and the more long it takes to type it
+// the more important will it be to train it
+func (s stats) trigramsToTrain() []TrigramScore {
+	res := make([]TrigramScore, 0, len(s.Trigrams))
+	sctx := s.scoringContext()
+	for t, ts := range s.Trigrams {
+		sc := Scoring.Score(ts.scoringStat(sctx), sctx)
+		res = append(res, TrigramScore{
+			Trigram: t,
+			Score:   sc,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score == res[j].Score { // keep order stable for reproducible exercises
+			return res[i].Trigram < res[j].Trigram
+		}
+		return res[i].Score > res[j].Score
+	})
+	return res
+}
+
+const NWeakest = 10
+
+// Number of weakest trigrams targeted by exercises made of real words
+const NTargets = 20
+
+// markovSequence generates text from Markov chain of given order, built from
+// trigram scores, starting from one of NWeakest trigrams
+func markovSequence(trigrams []TrigramScore, length, order int, rng *rand.Rand) string {
+	chain := trigramsChain(trigrams, order)
+	n := NWeakest
+	if n > len(trigrams) {
+		n = len(trigrams)
+	}
+	start := trigrams[rng.Intn(n)].Trigram
+	return chain.Generate(start, length, rng)
+}
+
+func (s *stats) addSession(session Session) {
+	text, errors, mistyped, typists := session.Text, session.Errors, session.Mistyped, session.Typists
+	timeline, paused, idle := activeTimeline(session.Timeline, s.PauseThreshold, s.PauseFactor)
+	s.SessionsCount++
+	s.TotalCharsTyped += len(text)
+	s.TotalSessionsDuration += timeline[len(timeline)-1]
+	s.TotalIdleDuration += idle
+	for i := 0; i < len(text)-3; i++ {
+		if paused[i+1] || paused[i+2] || paused[i+3] {
+			continue // duration of trigram typed with pause says nothing about skill
+		}
+		if len(mistyped) == len(text) && (mistyped[i] || mistyped[i+1] || mistyped[i+2]) {
+			continue // trigram was not typed correctly
+		}
+		if len(typists) == len(text) && (typists[i] != typists[i+1] || typists[i+1] != typists[i+2]) {
+			continue // trigram typed by two people is not a skill of any of them
+		}
+		k := string(text[i : i+3])
+		tr := s.Trigrams[k]
+		if !session.Training { // we do not count trigram frequencies in training sessions
+			tr.Count++ // because that will make them stuck in training longer
+		}
+		if tr.Duration.Version == 0 {
+			tr.Duration = NewStat(s.WindowCapacity, s.HalfLife)
+		}
+		tr.Duration.Append(timeline[i+3] - timeline[i])
+		if len(errors) == len(text) {
+			tr.Errors += errors[i+1] + errors[i+2]
+		}
+		s.Trigrams[k] = tr
+	}
+}
+
+// Store is stats and sessions log of one profile. Stores of different
+// profiles could be used at the same time, like by service training many
+// typists, without switching current profile. Store of one profile should
+// not be used concurrently.
+type Store struct {
+	profile string
+	files   fs.Files
+}
+
+// ProfileStore returns stats of given profile
+func ProfileStore(profile string) Store {
+	return Store{profile: profile, files: fs.ProfileFiles(profile)}
+}
+
+// CurrentStore returns stats of current profile
+func CurrentStore() Store {
+	return ProfileStore(fs.Profile)
+}
+
+// stats loaded for each profile
+var (
+	cacheLock  sync.Mutex
+	statsCache = make(map[string]*stats)
+)
+
+func loadStats() (*stats, error) {
+	return CurrentStore().load()
+}
+
+func (s Store) load() (*stats, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if st := statsCache[s.profile]; st != nil {
+		return st, nil
+	}
+	st := &stats{Trigrams: make(map[string]trigramStat)}
+	err := s.files.LoadJSON(StatsFile, st)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("Warning: File %s does not exist! It will be created.\n", StatsFile)
+			statsCache[s.profile] = st
+			return st, nil
+		}
+		return nil, err
+	}
+	st.migrate()
+	statsCache[s.profile] = st
+	return st, nil
+}
+
+// migrate converts trigram stats saved in older formats to the current one,
+// and applies configured window capacity and half-life
+func (s *stats) migrate() {
+	for t, tr := range s.Trigrams {
+		if tr.Legacy != nil && tr.Duration.Version == 0 {
+			tr.Duration = tr.Legacy.Migrate(s.WindowCapacity, s.HalfLife)
+			tr.Legacy = nil
+		}
+		if tr.Duration.Version != 0 {
+			tr.Duration.Configure(s.WindowCapacity, s.HalfLife)
+		}
+		s.Trigrams[t] = tr
+	}
+	s.Version = StatVersion
+}
+
+type statLogEntry struct {
+	Start    string                `json:"start"`
+	Text     string                `json:"text"`
+	Timeline []float64             `json:"timeline"`
+	Errors   []int                 `json:"errors,omitempty"`
+	Mistyped []int                 `json:"mistyped,omitempty"` // positions of characters left wrong
+	Typists  []TypistRun           `json:"typists,omitempty"`
+	Idle     float64               `json:"idle,omitempty"`
+	Seed     int64                 `json:"seed,omitempty"`
+	Mode     string                `json:"mode,omitempty"`
+	Pauses   []PauseInterval       `json:"pauses,omitempty"`
+	Result   *TestResult           `json:"result,omitempty"`
+	Latency  map[string]*Histogram `json:"latency,omitempty"`
+	Clocks   []ClockOffset         `json:"clocks,omitempty"`
+}
+
+// startTime returns time when session started, or zero time if it is malformed
+func (e statLogEntry) startTime() time.Time {
+	t, _ := time.Parse(time.RFC3339, e.Start)
+	return t
+}
+
+const wpmPer1secTrigramTime = 36.0 // 3 / 5 * 60
+func time2wpm(t float64) float64 {
+	return wpmPer1secTrigramTime / t
+}
+
+func AverageWPM() float64 {
+	return CurrentStore().AverageWPM()
+}
+
+// AverageWPM returns speed of typist, computed from average time of trigrams
+func (s Store) AverageWPM() float64 {
+	stats, err := s.load()
+	if err != nil { // If stats loaded to fail
+		return 50.0 // return world average
+	}
+	avDur := stats.AverageCharDuration() * 3.0
+	return time2wpm(avDur)
+}
+
+func GetReport() (string, error) {
+	stats, err := loadStats()
+	if err != nil {
+		return "", err
+	}
+	res := make([]string, 0)
+	print := func(f string, args ...interface{}) {
+		res = append(res, fmt.Sprintf(f, args...))
+	}
+	print("Total characters typed: %d\n", stats.TotalCharsTyped)
+	print("Total time in training: %s\n", time.Second*time.Duration(stats.TotalSessionsDuration))
+	print("Total time in pauses: %s\n", time.Second*time.Duration(stats.TotalIdleDuration))
+	print("Average typing speed: %.1f wpm\n", AverageWPM())
+	print("Training sessions: %d\n", stats.SessionsCount)
+	var fastestTr, slowestTr string
+	fastestTime := 10.0
+	slowestTime := 0.0
+	for t, s := range stats.Trigrams {
+		dur := s.Duration.Average(0)
+		if dur < fastestTime {
+			fastestTime = dur
+			fastestTr = t
+		}
+		if dur > slowestTime {
+			slowestTime = dur
+			slowestTr = t
+		}
+	}
+	print("\nTrigram stats:\n")
+	print("Slowest: %#v %4.2fs (%.1f wpm)\n", slowestTr, slowestTime, time2wpm(slowestTime))
+	print("Fastest: %#v %4.2fs (%.1f wpm)\n", fastestTr, fastestTime, time2wpm(fastestTime))
+
+	trigrams := stats.trigramsToTrain()
+	if len(trigrams) > 20 {
+		trigrams = trigrams[:20]
+	}
+	if len(trigrams) > 0 {
+		print("\nNeed to be trained most (scoring model: %s):\n", describeScoring(Scoring))
+		print("Trigram |   Score | Frequency | Typing time        | Median |   p90 | Std dev | Errors\n")
+		for _, t := range trigrams {
+			d := stats.Trigrams[t.Trigram]
+			tr := fmt.Sprintf("%#v", t.Trigram)
+			dur := d.Duration.Average(0)
+			print(
+				"%7s | %7.2f | %9d | %4.2fs (%5.1f wpm) | %5.2fs | %4.2fs | %6.2fs | %5.1f%%\n",
+				tr, t.Score/stats.TotalSessionsDuration*1000.0, d.Count, dur, time2wpm(dur),
+				d.Duration.Percentile(50), d.Duration.Percentile(90), d.Duration.StdDev(),
+				d.scoringStat(ScoringContext{}).ErrorRate()*100,
+			)
+			// we divide score to total session duration go get score approximated in promille
+			// if trigram will be the only one we type - it will have 1000 score,
+			// if it's current typing speed equals to total, average, or less if it is typed faster.
+			// if it is typed slower - score will be greater than 1000
+		}
+	}
+	tests, err := testsReport()
+	if err != nil {
+		return "", err
+	}
+	print("%s", tests)
+	if stats.TotalSessionsDuration < 600 { // Less than 10 minutes of training, not much to show
+		print("\nTrain more to get some progress!")
+		return strings.Join(res, ""), nil
+	}
+	progressInterval := time.Minute * 10      // Show progress in 10 minute intervals
+	if stats.TotalSessionsDuration > 2*3600 { // If trained for more than 2 hours - in 30 minutes intervals
+		progressInterval = time.Minute * 30
+	}
+	if stats.TotalSessionsDuration > 10*3600 { // If trained for more than 10 hours - in hour intervals
+		progressInterval = time.Minute * 30
+	}
+	progress, err := wpmProgress(progressInterval, stats.PauseThreshold, stats.PauseFactor)
+	if err != nil {
+		return "", err
+	}
+	print("\nTraining progress:\n")
+	print("   Time | WPM\n")
+	for i, wpm := range progress {
+		print("%7s | %.1f\n", formatDuration(time.Duration(i)*progressInterval), wpm)
+	}
+	return strings.Join(res, ""), nil
+}
+
+func formatDuration(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes())
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	if m == 0 {
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%dm", h, m-h*60)
+}
+
+const WPMinCPS = 12.0
+
+func calcWPM(chars int, seconds float64) float64 {
+	return float64(chars) / seconds * WPMinCPS
+}
+
+// wpmProgress computes typing speed in each interval of training time, not counting pauses
+func wpmProgress(intervalSize time.Duration, pauseThreshold, pauseFactor float64) ([]float64, error) {
+	logStatsIter, err := fs.NewJSONLinesIterator(LogStatsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer logStatsIter.Close()
+
+	var logEntry statLogEntry
+	iSec := intervalSize.Seconds()
+	var countedSeconds float64
+	var countedChars int
+	var res []float64
+	for {
+		cont, err := logStatsIter.UnmarshalNextLine(&logEntry)
+		if err != nil {
+			return nil, err
+		}
+		if !cont {
+			break
+		}
+		if len(logEntry.Timeline) == 0 { // test that ended before anything was typed
+			continue
+		}
+		logEntry.Timeline, _, _ = activeTimeline(logEntry.Timeline, pauseThreshold, pauseFactor)
+		for i, t := range logEntry.Timeline {
+			if t-countedSeconds >= iSec { // Counted approximately for interval
+				res = append(res, calcWPM(i-countedChars, t-countedSeconds))
+				countedSeconds = t
+				countedChars = i
+			}
+		}
+		// compute counting debt
+		countedSeconds = countedSeconds - logEntry.Timeline[len(logEntry.Timeline)-1]
+		countedChars = countedChars - len(logEntry.Timeline)
+	}
+	res = append(res, calcWPM(-countedChars, -countedSeconds))
+	return res, nil
+}
@@ -0,0 +1,23 @@
+package stats
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSeededGeneration(t *testing.T) {
+	trigrams := []TrigramScore{
+		{"abc", 5}, {"bca", 4}, {"cab", 3}, {"bcd", 3}, {"cda", 2},
+		{"dab", 2}, {"abd", 1}, {"bdc", 1}, {"dca", 1}, {"cac", 1},
+	}
+	a := markovSequence(trigrams, 50, 3, rand.New(rand.NewSource(42)))
+	b := markovSequence(trigrams, 50, 3, rand.New(rand.NewSource(42)))
+	if a != b {
+		t.Errorf("Same seed should generate same text, got %q and %q", a, b)
+	}
+	a = weakestSequence(trigrams, 20, 3, rand.New(rand.NewSource(7)))
+	b = weakestSequence(trigrams, 20, 3, rand.New(rand.NewSource(7)))
+	if a != b {
+		t.Errorf("Same seed should generate same text, got %q and %q", a, b)
+	}
+}
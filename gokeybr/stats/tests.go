@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bunyk/gokeybr/fs"
+)
+
+// TestResult is a standardized result of test session (timed, fixed word count,
+// or sudden death), comparable between sessions of the same test
+type TestResult struct {
+	Test string `json:"test"`
+	// Speed counting only characters typed right, in words (5 characters) per minute of test
+	WPM      float64 `json:"wpm"`
+	Accuracy float64 `json:"accuracy"` // percent of keys hit right
+	Chars    int     `json:"chars"`    // characters typed right
+	Seconds  float64 `json:"seconds"`  // duration of test
+}
+
+func (r TestResult) String() string {
+	return fmt.Sprintf(
+		"Test %s: %.1f wpm, accuracy %.1f%%, %d characters in %.1f seconds",
+		r.Test, r.WPM, r.Accuracy, r.Chars, r.Seconds,
+	)
+}
+
+// NewTestResult computes result of session, that took given number of seconds
+func NewTestResult(session Session, seconds float64) TestResult {
+	r := TestResult{Test: session.Test, Seconds: seconds, Accuracy: 100}
+	errors := 0
+	for i := range session.Text {
+		if i < len(session.Errors) {
+			errors += session.Errors[i]
+		}
+		if i >= len(session.Mistyped) || !session.Mistyped[i] {
+			r.Chars++
+		}
+	}
+	if keys := len(session.Text) + errors; keys > 0 {
+		r.Accuracy = float64(keys-errors) / float64(keys) * 100
+	}
+	if seconds > 0 {
+		r.WPM = calcWPM(r.Chars, seconds)
+	}
+	return r
+}
+
+// testsReport returns best and last results of each test from sessions log
+func testsReport() (string, error) {
+	iter, err := fs.NewJSONLinesIterator(LogStatsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer iter.Close()
+	type summary struct {
+		count      int
+		best, last TestResult
+	}
+	tests := make(map[string]*summary)
+	for {
+		var e statLogEntry
+		cont, err := iter.UnmarshalNextLine(&e)
+		if err != nil {
+			return "", err
+		}
+		if !cont {
+			break
+		}
+		if e.Result == nil {
+			continue
+		}
+		s := tests[e.Result.Test]
+		if s == nil {
+			s = &summary{best: *e.Result}
+			tests[e.Result.Test] = s
+		}
+		s.count++
+		s.last = *e.Result
+		if e.Result.WPM > s.best.WPM {
+			s.best = *e.Result
+		}
+	}
+	if len(tests) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(tests))
+	for n := range tests {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	res := []string{"\nTest results:\n", "Test                      | Count | Best wpm (accuracy) | Last wpm (accuracy)\n"}
+	for _, n := range names {
+		s := tests[n]
+		res = append(res, fmt.Sprintf(
+			"%-25s | %5d | %8.1f (%5.1f%%)   | %8.1f (%5.1f%%)\n",
+			n, s.count, s.best.WPM, s.best.Accuracy, s.last.WPM, s.last.Accuracy,
+		))
+	}
+	return strings.Join(res, ""), nil
+}
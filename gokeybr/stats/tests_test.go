@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShortTestIsLogged(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	statsCache = make(map[string]*stats)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// died on third key
+	died := Session{
+		Start: start, Text: []rune("ab"), Timeline: []float64{0, 0.2}, Errors: []int{0, 0},
+		Test: "sudden-death", Duration: 0.3,
+	}
+	if err := SaveSession(died); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSession(testSession(start, "abc")); err != nil { // not a test
+		t.Fatal(err)
+	}
+	// died on first key
+	died.Text, died.Timeline, died.Errors = nil, nil, nil
+	if err := SaveSession(died); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := readLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].Result == nil || log[0].Result.Chars != 2 || log[1].Result.Chars != 0 {
+		t.Fatalf("Results of short tests should be logged, got %+v", log)
+	}
+	report, err := testsReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report, "sudden-death              |     2 |") {
+		t.Errorf("Short tests should be in report, got %q", report)
+	}
+	st, err := loadStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.SessionsCount != 0 {
+		t.Errorf("Short sessions should not update stats, got %d sessions", st.SessionsCount)
+	}
+	if _, err := wpmProgress(time.Minute, 0, 0); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,189 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+const Capacity = 10
+
+// Window will hold last Capacity values in circular buffer to compute running averages
+//
+// Deprecated: Window is the format of stats files written before StatVersion 1.
+// It is kept only to be able to migrate old data, use Stat instead.
+type Window struct {
+	Length int           `json:"l"`
+	Index  int           `json:"i"`
+	Values [Capacity]int `json:"v"`
+}
+
+const MillisecondsInSecond = 1000.0
+
+func (w *Window) Append(val float64) {
+	w.Values[w.Index] = int(math.Round(val * 1000.0))
+	w.Index = (w.Index + 1) % Capacity
+	if w.Length < Capacity {
+		w.Length++
+	}
+}
+
+func (w Window) Average(def float64) float64 {
+	sum := 0.0
+	if w.Length == 0 {
+		return def
+	}
+	for i := 0; i < w.Length; i++ {
+		sum += float64(w.Values[i]) / 1000.0
+	}
+	return sum / float64(w.Length)
+}
+
+// values returns samples stored in window, oldest first
+func (w Window) values() []int {
+	if w.Length < Capacity {
+		return append([]int(nil), w.Values[:w.Length]...)
+	}
+	res := make([]int, 0, Capacity)
+	res = append(res, w.Values[w.Index:]...)
+	return append(res, w.Values[:w.Index]...)
+}
+
+// Migrate converts old window to Stat, replaying its samples from oldest to newest
+func (w Window) Migrate(capacity int, halfLife float64) Stat {
+	s := NewStat(capacity, halfLife)
+	for _, v := range w.values() {
+		s.Append(float64(v) / MillisecondsInSecond)
+	}
+	return s
+}
+
+// StatVersion is the version of Stat serialization format
+const StatVersion = 1
+
+// Defaults for Stat parameters, used when they are not configured
+const DefaultStatCapacity = 30
+const DefaultHalfLife = 10.0
+
+// Stat keeps last Capacity values (in milliseconds, oldest first) to compute
+// variance and percentiles, and exponentially weighted moving average of all
+// values appended, so older samples have less influence on the average.
+// HalfLife is number of samples after which weight of sample becomes twice smaller.
+type Stat struct {
+	Version  int     `json:"ver"`
+	Capacity int     `json:"cap"`
+	HalfLife float64 `json:"hl"`
+	Count    int     `json:"n"`
+	EWMA     float64 `json:"e"`
+	Values   []int   `json:"v"`
+}
+
+func NewStat(capacity int, halfLife float64) Stat {
+	if capacity <= 0 {
+		capacity = DefaultStatCapacity
+	}
+	if halfLife <= 0 {
+		halfLife = DefaultHalfLife
+	}
+	return Stat{
+		Version:  StatVersion,
+		Capacity: capacity,
+		HalfLife: halfLife,
+	}
+}
+
+// Configure changes capacity and half-life of stat, dropping oldest values
+// if they do not fit anymore
+func (s *Stat) Configure(capacity int, halfLife float64) {
+	c := NewStat(capacity, halfLife)
+	s.Version = c.Version
+	s.Capacity = c.Capacity
+	s.HalfLife = c.HalfLife
+	if len(s.Values) > s.Capacity {
+		s.Values = s.Values[len(s.Values)-s.Capacity:]
+	}
+}
+
+// alpha is a smoothing factor that gives HalfLife in samples
+func (s Stat) alpha() float64 {
+	return 1.0 - math.Pow(0.5, 1.0/s.HalfLife)
+}
+
+func (s *Stat) Append(val float64) {
+	if s.Version == 0 {
+		*s = NewStat(s.Capacity, s.HalfLife)
+	}
+	if s.Count == 0 {
+		s.EWMA = val
+	} else {
+		s.EWMA += s.alpha() * (val - s.EWMA)
+	}
+	s.Count++
+	s.Values = append(s.Values, int(math.Round(val*MillisecondsInSecond)))
+	if len(s.Values) > s.Capacity {
+		s.Values = s.Values[len(s.Values)-s.Capacity:]
+	}
+}
+
+// Len returns number of values in window
+func (s Stat) Len() int {
+	return len(s.Values)
+}
+
+// Average returns exponentially weighted moving average, or def if there are no values
+func (s Stat) Average(def float64) float64 {
+	if s.Count == 0 {
+		return def
+	}
+	return s.EWMA
+}
+
+// Mean returns plain average of values in window
+func (s Stat) Mean() float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range s.Values {
+		sum += float64(v)
+	}
+	return sum / float64(len(s.Values)) / MillisecondsInSecond
+}
+
+// Variance returns variance of values in window
+func (s Stat) Variance() float64 {
+	if len(s.Values) < 2 {
+		return 0
+	}
+	mean := s.Mean()
+	sum := 0.0
+	for _, v := range s.Values {
+		d := float64(v)/MillisecondsInSecond - mean
+		sum += d * d
+	}
+	return sum / float64(len(s.Values)-1)
+}
+
+func (s Stat) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Percentile returns p-th (0..100) percentile of values in window,
+// interpolating linearly between closest ranks
+func (s Stat) Percentile(p float64) float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), s.Values...)
+	sort.Ints(sorted)
+	rank := p / 100.0 * float64(len(sorted)-1)
+	if rank <= 0 {
+		return float64(sorted[0]) / MillisecondsInSecond
+	}
+	if rank >= float64(len(sorted)-1) {
+		return float64(sorted[len(sorted)-1]) / MillisecondsInSecond
+	}
+	lo := int(rank)
+	frac := rank - float64(lo)
+	v := float64(sorted[lo]) + frac*float64(sorted[lo+1]-sorted[lo])
+	return v / MillisecondsInSecond
+}
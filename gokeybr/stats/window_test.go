@@ -0,0 +1,62 @@
+package stats
+
+import "testing"
+
+func TestWindowCounting(t *testing.T) {
+	var w Window
+
+	if w.Average(45.0) != 45.0 {
+		t.Errorf("Average of 1 value 1.0 should equal to default")
+	}
+	w.Append(1.0)
+
+	got := w.Average(13.0)
+	if got != 1.0 {
+		t.Errorf("Average of 1 value 1.0 should be 1.0, got %f", got)
+	}
+}
+
+func TestStatDecay(t *testing.T) {
+	s := NewStat(4, 1.0)
+	if s.Average(2.0) != 2.0 {
+		t.Errorf("Average of empty stat should equal to default")
+	}
+	s.Append(1.0)
+	s.Append(3.0)
+	// with half-life of one sample, new value and previous average have equal weight
+	if got := s.Average(0); got != 2.0 {
+		t.Errorf("Expected average 2.0, got %f", got)
+	}
+	for i := 0; i < 5; i++ {
+		s.Append(0.5)
+	}
+	if s.Len() != 4 {
+		t.Errorf("Stat should keep only last 4 values, got %d", s.Len())
+	}
+	if got := s.Percentile(90); got != 0.5 {
+		t.Errorf("Old values should be dropped from window, got p90 %f", got)
+	}
+	if got := s.Variance(); got != 0 {
+		t.Errorf("Variance of equal values should be 0, got %f", got)
+	}
+}
+
+func TestWindowMigration(t *testing.T) {
+	var w Window
+	for i := 1; i <= Capacity+2; i++ {
+		w.Append(float64(i))
+	}
+	s := w.Migrate(20, 3.0)
+	if s.Len() != Capacity {
+		t.Fatalf("All %d values should be migrated, got %d", Capacity, s.Len())
+	}
+	if s.Values[0] != 3000 || s.Values[Capacity-1] != 12000 {
+		t.Errorf("Values should be migrated oldest first, got %v", s.Values)
+	}
+	if got := s.Percentile(50); got != 7.5 {
+		t.Errorf("Expected median 7.5, got %f", got)
+	}
+	if s.Average(0) <= s.Mean() {
+		t.Errorf("Average should follow recent (greater) values more than mean")
+	}
+}
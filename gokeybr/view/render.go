@@ -0,0 +1,329 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunyk/gokeybr/stats"
+	"github.com/gdamore/tcell/v2"
+)
+
+var doneStyle tcell.Style = tcell.StyleDefault.
+	Foreground(tcell.ColorGreen)
+
+var lifeStyle tcell.Style = tcell.StyleDefault.
+	Foreground(tcell.ColorPurple)
+
+var redBar = tcell.StyleDefault.
+	Background(tcell.ColorRed)
+
+var greenBar = tcell.StyleDefault.
+	Background(tcell.ColorGreen)
+
+var blackBar = tcell.StyleDefault.
+	Background(tcell.ColorDefault)
+
+var errorStyle = redBar.
+	Foreground(tcell.ColorBlack)
+
+type DisplayableData struct {
+	DoneText  []rune
+	Mistyped  []bool // for each character of DoneText, true if it was typed wrong
+	WrongText []rune
+	TODOText  []rune
+	Timeline  []float64
+	StartedAt time.Time
+	Elapsed   float64 // seconds since start, without pauses
+	Paused    bool
+	Timed     bool    // show time left instead of time since start
+	TimeLeft  float64 // seconds
+	Words     int
+	WordLimit int // show words typed of WordLimit, when set
+	WPM       float64
+	Life      float64
+	Zen       bool
+	Offset    int
+	Endless   bool   // text has no end, so there is no progress to show
+	Turn      string // whose turn is it to type in pair typing
+	Dropped   int    // number of remote events dropped because of overflow
+	Link      *LinkStatus
+}
+
+// LinkStatus is a state of remote input
+type LinkStatus struct {
+	State      string  // connecting, connected, disconnected, reconnected, closed or retrying
+	LastEvent  float64 // seconds since last remote event, negative when there were none
+	Backlog    int     // events received or stored, but not typed yet
+	AckPending int     // events delivered by JetStream, but not acknowledged yet
+	Latency    []Latency
+}
+
+// Latency is percentiles of some latency of remote keys, in milliseconds
+type Latency struct {
+	Name          string
+	P50, P95, P99 float64
+}
+
+var (
+	linkOKStyle   = tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	linkLagStyle  = tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	linkLostStyle = tcell.StyleDefault.Foreground(tcell.ColorRed)
+)
+
+// showLink writes compact status of remote input in top right corner, like
+// "NATS connected, last key 1.2s ago, backlog 0"
+func showLink(s tcell.Screen, w int, l *LinkStatus) {
+	status := "NATS " + l.State
+	if l.LastEvent >= 0 {
+		status += fmt.Sprintf(", last key %.1fs ago", l.LastEvent)
+	}
+	status += fmt.Sprintf(", backlog %d", l.Backlog)
+	if l.AckPending > 0 {
+		status += fmt.Sprintf(" (+%d unacked)", l.AckPending)
+	}
+	style := linkOKStyle
+	switch {
+	case l.State == "disconnected" || l.State == "closed" || l.State == "retrying":
+		style = linkLostStyle
+	case l.State == "connecting" || l.Backlog > 0 || l.AckPending > 0:
+		style = linkLagStyle
+	}
+	write(s, status, w-utf8.RuneCountInString(status)-2, 1, style)
+	if len(l.Latency) == 0 {
+		return
+	}
+	// like "capture-receive 5/9/12, receive-render 1/1/2 ms p50/p95/p99"
+	parts := make([]string, 0, len(l.Latency))
+	for _, l := range l.Latency {
+		parts = append(parts, fmt.Sprintf("%s %.0f/%.0f/%.0f", l.Name, l.P50, l.P95, l.P99))
+	}
+	lat := strings.Join(parts, ", ") + " ms p50/p95/p99"
+	write(s, lat, w-utf8.RuneCountInString(lat)-2, 2, tcell.StyleDefault)
+}
+
+func Render(s tcell.Screen, dd DisplayableData) {
+	s.Clear()
+	w, h := s.Size()
+
+	write3colors(s, dd.DoneText, dd.Mistyped, dd.WrongText, dd.TODOText, 2, 3, w-5, h-4)
+
+	if !dd.Zen {
+		if dd.Life > 0.0 {
+			for i := 0; i < w; i++ {
+				s.SetContent(i, 0, ' ', nil, blackBar)
+			}
+			for i := 0; i < int(float64(w)*dd.Life/3.0); i++ {
+				s.SetContent(i*3+1, 0, '♥', nil, lifeStyle)
+			}
+		}
+		if dd.Link != nil {
+			showLink(s, w, dd.Link)
+		}
+		if dd.Turn != "" {
+			write(s, fmt.Sprintf("Type this (%s turn):", dd.Turn), 2, 1, tcell.StyleDefault)
+		} else {
+			write(s, "Type this:", 2, 1, tcell.StyleDefault)
+		}
+
+		// Stats:
+		timer := "Go!"
+		if !dd.StartedAt.IsZero() {
+			timer = fmt.Sprintf("%.1f sec", dd.Elapsed)
+		}
+		if dd.Timed {
+			timer = fmt.Sprintf("%.1f sec left", dd.TimeLeft)
+		}
+		if dd.WordLimit > 0 {
+			timer = fmt.Sprintf("%s, %d/%d words", timer, dd.Words, dd.WordLimit)
+		}
+		if dd.Dropped > 0 {
+			timer = fmt.Sprintf("%s, %d keys dropped", timer, dd.Dropped)
+		}
+		// Show timer
+		x := (w - utf8.RuneCountInString(timer)) / 2
+		write(s, timer, x, h-1, tcell.StyleDefault)
+
+		// Show wpm
+		if dd.WPM > 0 {
+			speedometer := dd.WPM / stats.AverageWPM() // compute speed improvement relative to average
+			speedStyle := redBar                       // show slow speeds in red
+			if speedometer >= 0.90 {                   // Keeping in range of 90% of average speed is good
+				speedStyle = greenBar
+			}
+			speedometer = speedometer / 2.0 // so average speed is displayed at the middle of speedometer
+			if speedometer > 1.0 {
+				speedometer = 1.0
+			}
+			vBar(s, 0, 0, int(float64(h)*speedometer), speedStyle)
+
+			write(s, fmt.Sprintf("%.0f wpm", dd.WPM), 0, h-1, tcell.StyleDefault)
+		}
+
+		// Show progress
+		if !dd.Endless {
+			done := float64(len(dd.DoneText)) + float64(dd.Offset)
+			progress := done / (done + float64(len(dd.TODOText)+len(dd.WrongText)))
+			vBar(s, w-1, 0, int(float64(h)*progress), greenBar)
+			progressIndicator := fmt.Sprintf("%.1f%%", progress*100)
+			x = w - utf8.RuneCountInString(progressIndicator)
+			write(s, progressIndicator, x, h-1, tcell.StyleDefault)
+		}
+	}
+	if dd.Paused {
+		showPaused(s, w, h)
+	}
+	s.Show()
+}
+
+var pausedStyle = tcell.StyleDefault.
+	Background(tcell.ColorYellow).
+	Foreground(tcell.ColorBlack)
+
+// showPaused draws box with pause message in the middle of screen
+func showPaused(s tcell.Screen, w, h int) {
+	lines := []string{
+		"",
+		"   PAUSED   ",
+		"   Ctrl+P - continue, Esc - quit   ",
+		"",
+	}
+	width := 0
+	for _, l := range lines {
+		if n := utf8.RuneCountInString(l); n > width {
+			width = n
+		}
+	}
+	x := (w - width) / 2
+	y := (h - len(lines)) / 2
+	for i, l := range lines {
+		for j := 0; j < width; j++ {
+			s.SetContent(x+j, y+i, ' ', nil, pausedStyle)
+		}
+		write(s, l, x+(width-utf8.RuneCountInString(l))/2, y+i, pausedStyle)
+	}
+	s.HideCursor()
+}
+
+func vBar(scr tcell.Screen, x, y, h int, style tcell.Style) {
+	for i := 0; i < h; i++ {
+		scr.SetContent(x, y+i, ' ', nil, style)
+	}
+}
+
+func write(scr tcell.Screen, text string, x, y int, style tcell.Style) {
+	for _, c := range text {
+		scr.SetContent(x, y, c, nil, style)
+		x++
+	}
+}
+
+func write3colors(scr tcell.Screen, done []rune, mistyped []bool, wrong, todo []rune, x, y, w, h int) {
+	var cursorX, cursorY int
+	var style tcell.Style
+	var blank bool   // turns off printing for computing cursor position
+	var marks []bool // characters that should be printed with errorStyle
+
+	// put character on screen
+	putC := func(r rune) {
+		if blank {
+			return // this is just trial run
+		}
+		scr.SetContent(cursorX, cursorY, r, nil, style)
+	}
+	putS := func(s []rune) {
+		defaultStyle := style
+		for i, c := range s {
+			style = defaultStyle
+			if i < len(marks) && marks[i] {
+				style = errorStyle
+			}
+			if !blank && cursorY > y+h {
+				break // Do not type below allowed window
+			}
+			if !blank && cursorY == y+h {
+				c = '↡' // If we are on a lower border - show that there will be more text
+			}
+			if c == '\n' {
+				putC('⏎')
+				// move cursor to new line
+				cursorX = x
+				cursorY++
+				continue
+			}
+			// displayable spaces
+			if c == ' ' {
+				c = '␣'
+			}
+			putC(c)
+			cursorX++
+			if cursorX >= x+w { // line wrap
+				cursorX = x
+				cursorY++
+			}
+		}
+		style = defaultStyle
+	}
+
+	cursorX = x
+	cursorY = y
+	blank = true
+
+	putS(done)
+	putS(wrong)
+
+	// cursor will be in current position if we won't scroll
+
+	// but we will scroll following number of lines
+	scroll := cursorY - y - h/2
+
+	// TODO: maybe move this out
+	if scroll > 0 {
+		scrolledLines := 0
+		i := 0
+		var c rune
+		for i, c = range done {
+			if c == '\n' {
+				scrolledLines++
+			}
+			if scrolledLines >= scroll {
+				i++
+				break
+			}
+		}
+		done = done[i:]
+		if len(mistyped) >= i {
+			mistyped = mistyped[i:]
+		}
+		if len(done) == 0 && scrolledLines < scroll {
+			for i, c = range wrong {
+				if c == '\n' {
+					scrolledLines++
+				}
+				if scrolledLines >= scroll {
+					i++
+					break
+				}
+			}
+			wrong = wrong[i:]
+		}
+	}
+
+	cursorX = x
+	cursorY = y
+	blank = false
+
+	style = doneStyle
+	marks = mistyped
+	putS(done)
+	marks = nil
+
+	style = errorStyle
+	putS(wrong)
+
+	scr.ShowCursor(cursorX, cursorY)
+
+	style = tcell.StyleDefault
+	putS(todo)
+}
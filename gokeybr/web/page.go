@@ -0,0 +1,132 @@
+package web
+
+// page is typing page served to browser. It sends keys to /keys, one request
+// at a time so their order is kept, each key with its own time, and shows
+// progress received from /progress. Sessions are started with "start" control,
+// so key that starts them is not typed.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gokeybr</title>
+<style>
+body { background: #111; color: #ddd; font-family: monospace; font-size: 20px; margin: 2em; }
+#text { white-space: pre-wrap; line-height: 1.6; margin: 1em 0; }
+.done { color: #6c6; }
+.cursor { background: #ddd; color: #111; }
+.todo { color: #888; }
+#status { color: #aaa; }
+#summary { white-space: pre-wrap; color: #fc6; }
+input, button { font: inherit; }
+</style>
+</head>
+<body>
+<form id="login">
+  Name: <input id="name" autofocus> <button>Start</button>
+</form>
+<div id="trainer" hidden>
+  <div id="status">Waiting for session to start...</div>
+  <div id="text"></div>
+  <div id="summary"></div>
+</div>
+<script>
+var name = "";
+var pending = [];
+var control = "";
+var sending = false;
+var finished = false;
+
+function send() {
+  if (sending || (pending.length === 0 && !control)) {
+    return;
+  }
+  sending = true;
+  var req = {name: name, keys: pending, control: control, sent: performance.now()};
+  pending = [];
+  control = "";
+  fetch("/keys", {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify(req)
+  }).then(function (resp) {
+    if (!resp.ok) {
+      return resp.text().then(function (t) { status(t); });
+    }
+  }).catch(function (err) {
+    status("Failed to send keys: " + err);
+  }).then(function () {
+    sending = false;
+    send();
+  });
+}
+
+function status(s) {
+  document.getElementById("status").textContent = s;
+}
+
+function span(cls, text) {
+  var s = document.createElement("span");
+  s.className = cls;
+  s.textContent = text;
+  return s;
+}
+
+function show(p) {
+  if (p.Text) {
+    window.sessionText = Array.from(p.Text);
+    document.getElementById("summary").textContent = "";
+    finished = false;
+  }
+  var chars = window.sessionText || [];
+  var el = document.getElementById("text");
+  el.textContent = "";
+  el.appendChild(span("done", chars.slice(0, p.Typed).join("")));
+  el.appendChild(span("cursor", chars.slice(p.Typed, p.Typed + 1).join("")));
+  el.appendChild(span("todo", chars.slice(p.Typed + 1).join("")));
+  status(p.Typed + "/" + p.Length + " characters, " + p.Errors + " errors, " +
+    p.WPM.toFixed(1) + " wpm" + (p.Paused ? ", paused" : ""));
+  if (p.Done) {
+    finished = true;
+    document.getElementById("summary").textContent =
+      p.Summary + "\nPress Enter to start next session";
+  }
+}
+
+document.getElementById("login").addEventListener("submit", function (e) {
+  e.preventDefault();
+  name = document.getElementById("name").value.trim();
+  if (!name) {
+    return;
+  }
+  document.getElementById("login").hidden = true;
+  document.getElementById("trainer").hidden = false;
+  var events = new EventSource("/progress?name=" + encodeURIComponent(name));
+  events.onmessage = function (e) { show(JSON.parse(e.data)); };
+  events.onerror = function () { status("Lost connection to bridge, reconnecting..."); };
+  events.onopen = function () {
+    control = "start";
+    send();
+  };
+
+  document.addEventListener("keydown", function (e) {
+    if (e.metaKey) {
+      return;
+    }
+    if (e.key.length === 1 || e.key === "Backspace" || e.key === "Enter" || e.key === "Tab" || e.key === "Escape") {
+      e.preventDefault();
+    }
+    if (finished) {
+      if (e.key === "Enter") {
+        control = "start";
+        send();
+      }
+      return;
+    }
+    pending.push({key: e.key, ctrl: e.ctrlKey, alt: e.altKey, time: e.timeStamp});
+    send();
+  });
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,215 @@
+// Package web serves typing page for browser, and bridges its keys to NATS,
+// so typists without terminal could train with trainer or service.
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/bunyk/gokeybr/fs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+// Bridge publishes keys typed in browser to KeysSubject.<name>, and streams
+// progress published to ResultsSubject.<name> back to browser
+type Bridge struct {
+	KeysSubject    string
+	ResultsSubject string
+	nc             *nats.Conn
+}
+
+// NewBridge connects to NATS server
+func NewBridge(url, keys, results string) (*Bridge, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Bridge{KeysSubject: keys, ResultsSubject: results, nc: nc}, nil
+}
+
+func (b *Bridge) Close() {
+	b.nc.Close()
+}
+
+// Handler serves typing page on /, receives keys on /keys, and sends
+// progress with server-sent events on /progress
+func (b *Bridge) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/keys", b.keys)
+	mux.HandleFunc("/progress", b.progress)
+	return mux
+}
+
+// BrowserKey is a key as browser tells it in KeyboardEvent
+type BrowserKey struct {
+	Key  string  `json:"key"`
+	Ctrl bool    `json:"ctrl"`
+	Alt  bool    `json:"alt"`
+	Time float64 `json:"time"` // timeStamp of event, milliseconds on clock of page
+}
+
+// KeysRequest is sent by typing page, with keys typed since previous request
+type KeysRequest struct {
+	Name string       `json:"name"`
+	Keys []BrowserKey `json:"keys"`
+	// Time when request was sent, on the same clock of page as times of keys
+	Sent float64 `json:"sent"`
+	// Command for session, sent before keys
+	Control string `json:"control,omitempty"`
+}
+
+// controls page could send
+var controls = map[string]bool{app.ControlStart: true, app.ControlPause: true, app.ControlResume: true}
+
+// captured converts time of key on clock of page to time on clock of bridge,
+// by its age when request was sent. Time of request in network is not counted.
+func (r KeysRequest) captured(k BrowserKey, received time.Time) time.Time {
+	age := r.Sent - k.Time
+	if r.Sent == 0 || k.Time == 0 || age < 0 {
+		return received
+	}
+	return received.Add(-time.Duration(age * float64(time.Millisecond)))
+}
+
+// named keys of browser, other keys of more than one character are ignored
+var browserKeys = map[string]tcell.Key{
+	"Backspace": tcell.KeyBackspace2,
+	"Enter":     tcell.KeyEnter,
+	"Tab":       tcell.KeyTab,
+	"Escape":    tcell.KeyEscape,
+}
+
+// ctrlKeys are keys trainer reacts to when typed with Ctrl
+var ctrlKeys = map[string]tcell.Key{
+	"p": app.PauseKey,
+	"w": tcell.KeyCtrlW,
+}
+
+// Event converts browser key to key event for trainer, false is returned for
+// keys trainer does not need (Shift, arrows...)
+func (k BrowserKey) Event() (app.EventMsg, bool) {
+	var mod tcell.ModMask
+	if k.Ctrl {
+		mod |= tcell.ModCtrl
+	}
+	if k.Alt {
+		mod |= tcell.ModAlt
+	}
+	if key, ok := browserKeys[k.Key]; ok {
+		return app.EventMsg{Key: key, ModMask: mod}, true
+	}
+	runes := []rune(k.Key)
+	if len(runes) != 1 {
+		return app.EventMsg{}, false
+	}
+	if k.Ctrl {
+		key, ok := ctrlKeys[strings.ToLower(k.Key)]
+		if !ok {
+			return app.EventMsg{}, false
+		}
+		return app.EventMsg{Key: key, ModMask: mod}, true
+	}
+	return app.EventMsg{Key: tcell.KeyRune, Char: runes[0], ModMask: mod}, true
+}
+
+// validName checks that name could be token of NATS subject and name of profile
+func validName(name string) error {
+	if strings.ContainsAny(name, ".*> \t") {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return fs.ValidateProfileName(name)
+}
+
+// keys publishes keys from browser. Clock of page could differ from clock
+// of trainer, so keys are stamped on clock of bridge, by their age.
+func (b *Bridge) keys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "keys should be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req KeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validName(req.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Control != "" && !controls[req.Control] {
+		http.Error(w, fmt.Sprintf("unknown control %q", req.Control), http.StatusBadRequest)
+		return
+	}
+	received := time.Now()
+	var msgs []app.EventMsg
+	if req.Control != "" {
+		msgs = append(msgs, app.EventMsg{Control: req.Control, Time: received})
+	}
+	for _, k := range req.Keys {
+		if msg, ok := k.Event(); ok {
+			msg.Time = req.captured(k, received)
+			msgs = append(msgs, msg)
+		}
+	}
+	for _, msg := range msgs {
+		msg.Source = req.Name
+		msg.Published = time.Now()
+		data, err := json.Marshal(msg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := b.nc.Publish(b.KeysSubject+"."+req.Name, data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// progress streams progress of session of typist to browser, as server-sent events
+func (b *Bridge) progress(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if err := validName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	msgs := make(chan *nats.Msg, 64)
+	sub, err := b.nc.ChanSubscribe(b.ResultsSubject+"."+name, msgs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer sub.Unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-msgs:
+			fmt.Fprintf(w, "data: %s\n\n", msg.Data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bunyk/gokeybr/app"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+func TestBrowserKeyEvent(t *testing.T) {
+	cases := []struct {
+		key  BrowserKey
+		want app.EventMsg
+		ok   bool
+	}{
+		{BrowserKey{Key: "a"}, app.EventMsg{Key: tcell.KeyRune, Char: 'a'}, true},
+		{BrowserKey{Key: "ї"}, app.EventMsg{Key: tcell.KeyRune, Char: 'ї'}, true},
+		{BrowserKey{Key: " "}, app.EventMsg{Key: tcell.KeyRune, Char: ' '}, true},
+		{BrowserKey{Key: "Backspace", Ctrl: true}, app.EventMsg{Key: tcell.KeyBackspace2, ModMask: tcell.ModCtrl}, true},
+		{BrowserKey{Key: "W", Ctrl: true}, app.EventMsg{Key: tcell.KeyCtrlW, ModMask: tcell.ModCtrl}, true},
+		{BrowserKey{Key: "p", Ctrl: true}, app.EventMsg{Key: app.PauseKey, ModMask: tcell.ModCtrl}, true},
+		{BrowserKey{Key: "c", Ctrl: true}, app.EventMsg{}, false},
+		{BrowserKey{Key: "Shift"}, app.EventMsg{}, false},
+	}
+	for _, c := range cases {
+		got, ok := c.key.Event()
+		if ok != c.ok || got != c.want {
+			t.Errorf("%+v: got %+v, %v, want %+v, %v", c.key, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	srv := app.StartTestServer(t)
+
+	b, err := NewBridge(srv.ClientURL(), "keys", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	hs := httptest.NewServer(b.Handler())
+	defer hs.Close()
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	keys, _ := nc.SubscribeSync("keys.alice")
+	nc.Flush()
+
+	resp, err := http.Post(hs.URL+"/keys", "application/json",
+		strings.NewReader(`{"name": "alice", "keys": [{"key": "h"}, {"key": "Shift"}, {"key": "i"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /keys: %s", resp.Status)
+	}
+	typed := ""
+	for i := 0; i < 2; i++ {
+		msg, err := keys.NextMsg(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m app.EventMsg
+		json.Unmarshal(msg.Data, &m)
+		if m.Source != "alice" || m.Time.IsZero() {
+			t.Errorf("Published %+v", m)
+		}
+		typed += string(m.Char)
+	}
+	if typed != "hi" {
+		t.Errorf("Published keys %q, want hi", typed)
+	}
+
+	// keys typed 250ms apart, and sent 100ms after the last one
+	before := time.Now()
+	resp, err = http.Post(hs.URL+"/keys", "application/json", strings.NewReader(
+		`{"name": "alice", "control": "start", "sent": 1350,
+		"keys": [{"key": "a", "time": 1000}, {"key": "b", "time": 1250}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var published []app.EventMsg
+	for i := 0; i < 3; i++ {
+		msg, err := keys.NextMsg(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m app.EventMsg
+		json.Unmarshal(msg.Data, &m)
+		published = append(published, m)
+	}
+	if published[0].Control != app.ControlStart {
+		t.Errorf("Control should be published before keys, got %+v", published[0])
+	}
+	first, last := published[1].Time, published[2].Time
+	if gap := last.Sub(first); gap != 250*time.Millisecond {
+		t.Errorf("Keys typed 250ms apart are %s apart", gap)
+	}
+	if age := time.Since(last); last.Before(before.Add(-100*time.Millisecond)) || age < 100*time.Millisecond {
+		t.Errorf("Last key should be captured 100ms before request was received, it is %s old", age)
+	}
+
+	resp, err = http.Post(hs.URL+"/keys", "application/json", strings.NewReader(`{"name": "a.b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Name with dot should be rejected, got %s", resp.Status)
+	}
+	resp, err = http.Post(hs.URL+"/keys", "application/json", strings.NewReader(`{"name": "alice", "control": "quit"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unknown control should be rejected, got %s", resp.Status)
+	}
+
+	resp, err = http.Get(hs.URL + "/progress?name=alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	nc.Publish("results.alice", []byte(`{"Typed": 2}`))
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "data: {\"Typed\": 2}\n" {
+		t.Errorf("Got event %q, %v", line, err)
+	}
+}
+
+func TestBridgeToService(t *testing.T) {
+	srv := app.StartTestServer(t)
+	saved := make(chan []float64, 1)
+	s := &app.Service{
+		URL:            srv.ClientURL(),
+		Subject:        "keys.*",
+		ResultsSubject: "results",
+		NewSession: func(typist string) (*app.App, error) {
+			return app.NewSession("abc", app.SystemClock), nil
+		},
+		Save: func(typist string, a *app.App) error {
+			saved <- a.Timeline
+			return nil
+		},
+		OnError: func(typist string, err error) { t.Error(typist, err) },
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	b, err := NewBridge(srv.ClientURL(), "keys", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	hs := httptest.NewServer(b.Handler())
+	defer hs.Close()
+
+	// keys arrive together, but keep gaps they were typed with
+	resp, err := http.Post(hs.URL+"/keys", "application/json", strings.NewReader(
+		`{"name": "alice", "sent": 1500,
+		"keys": [{"key": "a", "time": 1000}, {"key": "b", "time": 1250}, {"key": "c", "time": 1400}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	select {
+	case timeline := <-saved:
+		want := []float64{0, 0.25, 0.4}
+		for i, w := range want {
+			if math.Abs(timeline[i]-w) > 1e-6 {
+				t.Errorf("Saved timeline %v, want %v", timeline, want)
+				break
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Session was not saved")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -36,8 +37,12 @@ func displayHelloWorld(s tcell.Screen) {
 	s.Show()
 }
 
+var name = flag.String("name", "", "Name of typist, so trainer could tell who typed what")
+
 var (
-	subject = "events.key"
+	// trainer asks for time on this subject, to correct for clock difference
+	clockSubject = "gokeybr.clock"
+	subject      = "events.key"
 )
 
 type EventMsg struct {
@@ -45,10 +50,31 @@ type EventMsg struct {
 	ModMask tcell.ModMask
 	Key     tcell.Key
 	Char    rune
+	Source  string `json:",omitempty"`
+	// Time is when key was captured, Published - when it was sent
+	Published time.Time
+}
+
+// answerClock answers time requests of trainer, like NTP server
+func answerClock(nc *nats.Conn, name string) error {
+	_, err := nc.Subscribe(clockSubject, func(m *nats.Msg) {
+		t1 := time.Now()
+		var req struct{ T0 time.Time }
+		if err := json.Unmarshal(m.Data, &req); err != nil {
+			return
+		}
+		reply, _ := json.Marshal(struct {
+			T0, T1, T2 time.Time
+			Source     string
+		}{req.T0, t1, time.Now(), name})
+		m.Respond(reply)
+	})
+	return err
 }
 
 // This program just prints "Hello, World!".  Press ESC to exit.
 func main() {
+	flag.Parse()
 	var err error
 
 	nc, err := nats.Connect(nats.DefaultURL)
@@ -64,6 +90,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := answerClock(nc, *name); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
 	encoding.Register()
 
 	s, e := tcell.NewScreen()
@@ -94,7 +125,9 @@ func main() {
 				ModMask: ev.Modifiers(),
 				Key:     ev.Key(),
 				Char:    ev.Rune(),
+				Source:  *name,
 			}
+			msg.Published = time.Now()
 			data, err := json.Marshal(msg)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "json marshal, err: %v\n", err)
@@ -1,9 +0,0 @@
-package main
-
-import (
-	"github.com/bunyk/gokeybr/cmd"
-)
-
-func main() {
-	cmd.Execute()
-}
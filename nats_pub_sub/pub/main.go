@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -35,8 +37,12 @@ func displayHelloWorld(s tcell.Screen) {
 	s.Show()
 }
 
+var name = flag.String("name", "", "Name of typist, so trainer could tell who typed what")
+
 var (
-	subject = "foo.bar"
+	// trainer asks for time on this subject, to correct for clock difference
+	clockSubject = "gokeybr.clock"
+	subject      = "foo.bar"
 )
 
 type EventMsg struct {
@@ -44,10 +50,36 @@ type EventMsg struct {
 	ModMask tcell.ModMask
 	Key     tcell.Key
 	Char    rune
+	Source  string `json:",omitempty"`
+	// Time is when key was captured, Published - when it was sent
+	Published time.Time
+}
+
+// answerClock answers time requests of trainer, like NTP server
+func answerClock(nc *nats.Conn, name string) error {
+	_, err := nc.Subscribe(clockSubject, func(m *nats.Msg) {
+		t1 := time.Now()
+		var req struct{ T0 time.Time }
+		if err := json.Unmarshal(m.Data, &req); err != nil {
+			return
+		}
+		reply, _ := json.Marshal(struct {
+			T0, T1, T2 time.Time
+			Source     string
+		}{req.T0, t1, time.Now(), name})
+		m.Respond(reply)
+	})
+	return err
 }
 
 // This program just prints "Hello, World!".  Press ESC to exit.
+// "pub simulate" publishes keys of synthetic typists instead.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		simulate(os.Args[2:])
+		return
+	}
+	flag.Parse()
 	nc, err := nats.Connect(nats.DefaultURL)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -68,6 +100,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%v\n", e)
 		os.Exit(1)
 	}
+
+	if err := answerClock(nc, *name); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 	if e := s.Init(); e != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", e)
 		os.Exit(1)
@@ -91,7 +128,9 @@ func main() {
 				ModMask: ev.Modifiers(),
 				Key:     ev.Key(),
 				Char:    ev.Rune(),
+				Source:  *name,
 			}
+			msg.Published = time.Now()
 			if err := ec.Publish(subject, msg); err != nil {
 				fmt.Fprintf(os.Stderr, "failed to publish, err: %v\n", err)
 			}
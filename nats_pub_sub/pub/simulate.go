@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+)
+
+const defaultSimText = "The quick brown fox jumps over the lazy dog."
+
+// Distributions of jitter added to gaps between simulated keys
+var jitters = []string{"none", "uniform", "normal", "lognormal"}
+
+// typistModel tells how simulated typist types
+type typistModel struct {
+	wpm    float64
+	jitter string
+	spread float64 // relative to gap: half-width for uniform, standard deviation for others
+	errors float64 // probability to hit wrong key, which is then corrected with Backspace
+	// average duration of typing trigram, in seconds, from stats.json of real typist
+	trigrams map[string]float64
+}
+
+// simKey is a key to publish, after waiting gap since previous one
+type simKey struct {
+	gap  time.Duration
+	key  tcell.Key
+	char rune
+}
+
+// charGap returns time to wait before typing text[i]
+func (m typistModel) charGap(text []rune, i int) float64 {
+	if i >= 3 {
+		if d, ok := m.trigrams[string(text[i-3:i])]; ok {
+			return d / 3 // trigram duration spans three keys
+		}
+	}
+	return 60 / (m.wpm * 5) // word is 5 characters
+}
+
+func (m typistModel) jittered(gap float64, rng *rand.Rand) float64 {
+	switch m.jitter {
+	case "uniform":
+		gap *= 1 + m.spread*(2*rng.Float64()-1)
+	case "normal":
+		gap *= 1 + m.spread*rng.NormFloat64()
+	case "lognormal": // long tail of slow keys, like real typing
+		gap *= math.Exp(m.spread*rng.NormFloat64() - m.spread*m.spread/2)
+	}
+	if gap < 0.01 {
+		gap = 0.01
+	}
+	return gap
+}
+
+// wrongKey returns key typist hits instead of c
+func wrongKey(c rune, rng *rand.Rand) rune {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	for {
+		w := rune(letters[rng.Intn(len(letters))])
+		if w != c {
+			return w
+		}
+	}
+}
+
+// plan returns keys to type text. Same seed gives the same keys and gaps,
+// so trainer could be tested deterministically.
+func (m typistModel) plan(text string, rng *rand.Rand) []simKey {
+	runes := []rune(text)
+	var keys []simKey
+	sec := func(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+	for i, c := range runes {
+		gap := m.charGap(runes, i)
+		if rng.Float64() < m.errors {
+			keys = append(keys,
+				simKey{gap: sec(m.jittered(gap, rng)), key: tcell.KeyRune, char: wrongKey(c, rng)},
+				// noticing error takes longer than typing key
+				simKey{gap: sec(m.jittered(3*gap, rng)), key: tcell.KeyBackspace2},
+			)
+		}
+		keys = append(keys, simKey{gap: sec(m.jittered(gap, rng)), key: tcell.KeyRune, char: c})
+	}
+	return keys
+}
+
+// simulatedTypist publishes keys of plan to subject, loops times (0 - forever)
+func simulatedTypist(nc *nats.Conn, subject, name string, keys []simKey, loops int) error {
+	for l := 0; loops == 0 || l < loops; l++ {
+		for _, k := range keys {
+			time.Sleep(k.gap)
+			msg := EventMsg{Time: time.Now(), Key: k.key, Char: k.char, Source: name}
+			msg.Published = time.Now()
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			if err := nc.Publish(subject, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nc.Flush()
+}
+
+// loadTrigrams reads average trigram durations from stats.json of gokeybr
+func loadTrigrams(path string) (map[string]float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st struct {
+		Trigrams map[string]struct {
+			Duration struct {
+				Count int     `json:"n"`
+				EWMA  float64 `json:"e"`
+			} `json:"s"`
+		}
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	res := make(map[string]float64, len(st.Trigrams))
+	for t, tr := range st.Trigrams {
+		if tr.Duration.Count > 0 {
+			res[t] = tr.Duration.EWMA
+		}
+	}
+	return res, nil
+}
+
+func fail(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// simulate runs "pub simulate": synthetic typists publishing keys without a human
+func simulate(args []string) {
+	f := flag.NewFlagSet("simulate", flag.ExitOnError)
+	url := f.String("url", nats.DefaultURL, "NATS server URL")
+	subj := f.String("subject", subject, "Subject to publish keys to. With several typists, name of typist is appended to it")
+	simName := f.String("name", "sim", "Name of typist. With several typists, they are numbered: sim1, sim2...")
+	n := f.Int("n", 1, "Number of concurrent typists")
+	text := f.String("text", defaultSimText, "Text to type")
+	file := f.String("file", "", "File with text to type, instead of -text")
+	wpm := f.Float64("wpm", 60, "Typing speed, for trigrams not found in -stats")
+	jitter := f.String("jitter", "normal", "Distribution of gaps between keys: "+strings.Join(jitters, ", "))
+	spread := f.Float64("spread", 0.25, "Spread of jitter, relative to gap")
+	errRate := f.Float64("errors", 0.02, "Probability of wrong key, corrected with Backspace")
+	statsFile := f.String("stats", "", "stats.json of gokeybr to take speeds of trigrams from, like ~/.gokeybr/stats.json")
+	seed := f.Int64("seed", 1, "Seed of randomness, typist i uses seed+i")
+	loops := f.Int("loops", 1, "How many times to type text (0 - forever)")
+	f.Parse(args)
+
+	m := typistModel{wpm: *wpm, jitter: *jitter, spread: *spread, errors: *errRate}
+	valid := false
+	for _, j := range jitters {
+		valid = valid || j == m.jitter
+	}
+	if !valid {
+		fail(fmt.Errorf("unknown jitter %q, available: %s", m.jitter, strings.Join(jitters, ", ")))
+	}
+	if m.wpm <= 0 {
+		fail(fmt.Errorf("wpm should be positive"))
+	}
+	if *file != "" {
+		data, err := ioutil.ReadFile(*file)
+		fail(err)
+		*text = strings.Join(strings.Fields(string(data)), " ")
+	}
+	if *statsFile != "" {
+		var err error
+		m.trigrams, err = loadTrigrams(*statsFile)
+		fail(err)
+	}
+
+	nc, err := nats.Connect(*url)
+	fail(err)
+	defer nc.Drain()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *n; i++ {
+		typist, typistSubject := *simName, *subj
+		if *n > 1 {
+			typist = fmt.Sprintf("%s%d", *simName, i+1)
+			typistSubject += "." + typist
+		}
+		fail(answerClock(nc, typist))
+		keys := m.plan(*text, rand.New(rand.NewSource(*seed+int64(i))))
+		fmt.Printf("%s types %d keys to %s\n", typist, len(keys), typistSubject)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := simulatedTypist(nc, typistSubject, typist, keys, *loops); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", typist, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPlanIsDeterministic(t *testing.T) {
+	for _, jitter := range jitters {
+		m := typistModel{wpm: 60, jitter: jitter, spread: 0.3, errors: 0.1}
+		a := m.plan(defaultSimText, rand.New(rand.NewSource(7)))
+		b := m.plan(defaultSimText, rand.New(rand.NewSource(7)))
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%s: same seed gave different plans", jitter)
+		}
+		for _, k := range a {
+			if k.gap <= 0 {
+				t.Errorf("%s: gap %s is not positive", jitter, k.gap)
+			}
+		}
+	}
+}
+
+func TestPlanCorrectsErrors(t *testing.T) {
+	m := typistModel{wpm: 60, jitter: "none", errors: 0.5}
+	keys := m.plan(defaultSimText, rand.New(rand.NewSource(1)))
+	typed := []rune{}
+	wrong := 0
+	for i, k := range keys {
+		if k.key == tcell.KeyBackspace2 {
+			typed = typed[:len(typed)-1]
+			continue
+		}
+		typed = append(typed, k.char)
+		if k.char != []rune(defaultSimText)[len(typed)-1] {
+			wrong++
+			if i+1 == len(keys) || keys[i+1].key != tcell.KeyBackspace2 {
+				t.Fatalf("Wrong key %q at %d is not followed by Backspace", k.char, i)
+			}
+		}
+	}
+	if wrong == 0 {
+		t.Error("No wrong keys with error rate 0.5")
+	}
+	if string(typed) != defaultSimText {
+		t.Errorf("Typed %q, want %q", string(typed), defaultSimText)
+	}
+}
+
+func TestLoadTrigrams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stats.json")
+	data := `{"Trigrams": {"the": {"c": 5, "s": {"n": 3, "e": 0.3}}, "xyz": {"c": 1, "s": {"n": 0}}}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	trigrams, err := loadTrigrams(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trigrams) != 1 || trigrams["the"] != 0.3 {
+		t.Errorf("Loaded %v, want only the: 0.3", trigrams)
+	}
+	m := typistModel{wpm: 60, trigrams: trigrams}
+	if gap := m.charGap([]rune("they"), 3); math.Abs(gap-0.1) > 1e-9 {
+		t.Errorf("Gap after \"the\" = %f, want 0.1", gap)
+	}
+	if gap := m.charGap([]rune("they"), 1); gap != 0.2 {
+		t.Errorf("Gap without trigram = %f, want 0.2 (60 wpm)", gap)
+	}
+}